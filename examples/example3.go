@@ -0,0 +1,29 @@
+package main
+
+// MUST CHECK ERROR
+
+type testCloser struct{}
+
+//!PARANO__MUST_CHECK_ERROR
+func (c *testCloser) Close() error {
+	return nil
+}
+
+// embeds a pointer to itself, which shall not make the type resolution loop
+type testSelfEmbedding struct {
+	*testSelfEmbedding
+	*testCloser
+}
+
+func testMustCheckError(c *testCloser, closers []*testCloser, s testSelfEmbedding) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	c.Close()
+	_ = closers[0].Close()
+	for _, c2 := range closers {
+		defer c2.Close()
+	}
+	s.testCloser.Close()
+	return s.Close()
+}
@@ -0,0 +1,35 @@
+package main
+
+// MUST USE
+
+type testSettings struct {
+	timeout int
+}
+
+//!PARANO__MUST_USE
+func (s testSettings) withTimeout(timeout int) testSettings {
+	s.timeout = timeout
+	return s
+}
+
+//!PARANO__MUST_USE
+type testQuery struct {
+	where string
+}
+
+func newTestQuery(where string) *testQuery {
+	return &testQuery{where: where}
+}
+
+func testMustUse(s testSettings, all []testSettings) testSettings {
+	s = s.withTimeout(1)
+	_ = s.withTimeout(2)
+	s.withTimeout(3)
+	all[0].withTimeout(4)
+	var f = s.withTimeout
+	f(5)
+	var q = newTestQuery("a")
+	_ = q
+	newTestQuery("b")
+	return s
+}
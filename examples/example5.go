@@ -0,0 +1,14 @@
+package main
+
+import (
+	"./examplesub"
+)
+
+// DEPRECATED
+
+func testDeprecated(c examplesub.TestConfig) string {
+	examplesub.Exec("DELETE FROM elements")
+	examplesub.QueryNoAnswer("DELETE FROM elements")
+	c.Name = c.Label
+	return c.OldName()
+}
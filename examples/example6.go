@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+)
+
+// GUARDED BY
+
+var testCacheMu sync.Mutex
+
+type testStore struct {
+	mu sync.RWMutex
+	//!PARANO__GUARDED_BY mu
+	items map[string]int
+	//!PARANO__GUARDED_BY testCacheMu
+	cache map[string]int
+}
+
+func newTestStore() *testStore {
+	var s = &testStore{}
+	s.items = make(map[string]int)
+	return s
+}
+
+func (s *testStore) get(k string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.items[k]
+}
+
+func (s *testStore) set(k string, v int) {
+	s.items[k] = v
+}
+
+func (s *testStore) setWithReadLock(k string, v int) {
+	s.mu.RLock()
+	s.items[k] = v
+	s.mu.RUnlock()
+}
+
+//!PARANO__REQUIRES_LOCK mu
+func (s *testStore) setLocked(k string, v int) {
+	s.items[k] = v
+}
+
+func (s *testStore) setAll(kind int, v int) {
+	s.mu.Lock()
+	switch kind {
+	case 0:
+		break
+	default:
+		s.mu.Unlock()
+		return
+	}
+	s.setLocked("a", v)
+	s.mu.Unlock()
+	s.setLocked("b", v)
+	go func() {
+		s.items["c"] = v
+	}()
+}
+
+func (s *testStore) cached(k string) int {
+	testCacheMu.Lock()
+	defer testCacheMu.Unlock()
+	return s.cache[k]
+}
+
+func (s *testStore) cachedWithoutLock(k string) int {
+	return s.cache[k]
+}
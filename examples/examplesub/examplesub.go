@@ -13,3 +13,21 @@ func Query(whatever bool, str string, arg1 bool, arg2 string) [][]interface{} {
 func QueryNoAnswer(str string) {
 
 }
+
+//!PARANO__DEPRECATED use=QueryNoAnswer since=2020-01
+func Exec(str string) {
+	QueryNoAnswer(str)
+}
+
+type TestConfig struct {
+	//!PARANO__DEPRECATED use=TestConfig.Name
+	Label string
+	Name  string
+}
+
+// OldName returns the name.
+//
+// Deprecated: use the field Name instead.
+func (c TestConfig) OldName() string {
+	return c.Label // same file: ignored
+}
@@ -4,10 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strconv"
+	"os/exec"
 	"strings"
 
 	"github.com/phrounz/go-parano/src"
+	"github.com/phrounz/go-parano/src/sqlparser"
 	"github.com/phrounz/go-parano/src/util"
 )
 
@@ -27,12 +28,59 @@ func main() {
 	var sqlQueryFunctionNamePtr = flag.String("sql-query-func-name", "", "Name of the function used for queries in the source code.\n"+
 		"- You may provide several function names, separated by comma.\n"+
 		"- Each function name must contain as suffix a colon followed by the argument index \n"+
-		"  (starting from 1) containing the query, e.g. \":2\" is the second function argument.")
+		"  (starting from 1) containing the query, e.g. \":2\" is the second function argument.\n"+
+		"- A method may be given by the type of its receiver, e.g. \"(*database/sql.DB).QueryContext:2\".")
+	var sqlQueryPresetPtr = flag.String("sql-query-preset", "", "Well-known database libraries whose functions and methods are used for queries, comma-separated,\n"+
+		"among: database/sql, sqlx, pgx, gorm.")
+	var sqlQueryStructFieldPtr = flag.String("sql-query-struct-field", "", "Struct fields containing a query, comma-separated, e.g. mypkg.Statement.SQL:\n"+
+		"the struct literals and the assignments to these fields are checked.")
 	var sqlQueryLintBinaryPtr = flag.String("sql-query-lint-binary", "", "SQL query lint program")
+	var sqlQueryLintFormatPtr = flag.String("sql-query-lint-format", "text", "Format of the output of -sql-query-lint-binary: text (any output with a nonzero exit code is an error),\n"+
+		"phpmyadmin, sqlfluff-json, or regex:<pattern> with named groups among offset, line, column, severity, rule and message.")
+	var sqlQueryLintIgnoreRulesPtr = flag.String("sql-query-lint-ignore-rules", "", "Rules of -sql-query-lint-binary whose errors are ignored, comma-separated, e.g. L010,L014.")
+	var sqlQueryLintJobsPtr = flag.Int("sql-query-lint-jobs", 1, "Number of -sql-query-lint-binary programs run at the same time.")
+	var sqlQueryLintTimeoutPtr = flag.Duration("sql-query-lint-timeout", 0, "Timeout of each run of -sql-query-lint-binary (or of each query with -sql-query-lint-server), e.g. 30s (default: none).")
+	var sqlQueryLintServerPtr = flag.Bool("sql-query-lint-server", false, "If set, run -sql-query-lint-binary once (for each job), and send it the queries line by line:\n"+
+		"each query as a JSON string, to which it replies with a line {\"exit_code\": N, \"output\": \"...\"}.")
+	var sqlQueryInjectionPtr = flag.Bool("sql-query-injection", false, "If set, report the SQL queries in which strings which are not constants are concatenated or formatted (SQL injections).")
+	var sqlQuerySanitizersPtr = flag.String("sql-query-sanitizers", "", "Functions and methods whose result may be put in a query for -sql-query-injection, comma-separated,\n"+
+		"e.g. mypkg.QuoteName,(*mypkg.Filter).SQL (in addition to strconv.Itoa, pq.QuoteIdentifier, ...).")
+	var sqlQueryRulesPtr = flag.String("sql-query-rules", "", "Policy rules checked on the SQL queries with the built-in SQL parser, comma-separated, or all:\n"+
+		"select-star, missing-where, drop-outside-migrations, missing-limit-in-handler.")
+	var sqlQueryMigrationDirsPtr = flag.String("sql-query-migration-dirs", "migration*,*migrations", "Directories of the migration packages, in which the rule drop-outside-migrations allows DROP and TRUNCATE, comma-separated.")
+	var sqlQueryHTTPHandlerTypesPtr = flag.String("sql-query-http-handler-types", "net/http.Request", "Types of the parameters of the HTTP handlers, for the rule missing-limit-in-handler, comma-separated,\n"+
+		"e.g. net/http.Request,github.com/gin-gonic/gin.Context.")
+	var sqlQueryExportPtr = flag.String("sql-query-export", "", "Writes the SQL queries found to this file, as JSON (.json) or CSV (.csv): query, complete, call, function, location\n"+
+		"and statement_type (may be used without any linter).")
+	var cacheDirPtr = flag.String("cache-dir", "", "Directory of the cache of the outputs of -sql-query-lint-binary (default: go-parano in the user cache directory).")
+	var cacheClearPtr = flag.Bool("cache-clear", false, "If set, empty the cache of the outputs of -sql-query-lint-binary before checking the queries.")
+	var noCachePtr = flag.Bool("no-cache", false, "If set, do not use the cache of the outputs of -sql-query-lint-binary.")
+	var sqlQueryLintBuiltinPtr = flag.String("sql-query-lint-builtin", "", "Checks the syntax of the SQL queries with the built-in SQL parser,\n"+
+		"for this dialect: mysql, postgres or sqlite (may be used instead of, or in addition to, -sql-query-lint-binary).")
+	var sqlQueryDialectPtr = flag.String("sql-query-dialect", "", "Dialect of the SQL queries: mysql, postgres or sqlite (default: the one of -sql-query-lint-builtin).\n"+
+		"Placeholders are replaced before running -sql-query-lint-binary, and their number is checked against the arguments.")
+	var sqlQuerySchemaPtr = flag.String("sql-query-schema", "", "Checks that the tables and the columns used by the SQL queries exist, with the built-in SQL parser:\n"+
+		"directory of migration files (*.sql, in alphabetical order, except *.down.sql) or a single file (e.g. a dump).")
 	var sqlQueryAllInOnePtr = flag.Bool("sql-query-all-in-one", false, "If set, run the SQL query lint program once with all the queries as argument, instead of running once by query.")
 	var sqlQueryIgnoreGoFilesPtr = flag.String("sql-query-ignore-go-files", "", "List of files to ignore when using -dir or -pkg, comma-separated, specific to sql-query feature.")
 	var ignoreGoFilesPtr = flag.String("ignore-go-files", "", "List of files to ignore when using -dir or -pkg, comma-separated.")
 	var ignorePrivateToFilePtr = flag.String("ignore-private-to-file", "", "List of functions/variables which shall be ignored when checking private-to-file, comma-separated.")
+	var immutableAllowedFuncsPtr = flag.String("immutable-allowed-funcs", "", "List of functions which may modify the variables declared with //!PARANO__IMMUTABLE\n"+
+		"(in addition to the init() functions), comma-separated.")
+	var embeddedLangBuiltinPtr = flag.Bool("embedded-lang-builtin", false, "If set, checks the strings passed to the functions of the standard library in another language:\n"+
+		"regexp.MustCompile(), (*text/template.Template).Parse(), json.Unmarshal(), time.Parse(), ...")
+	var embeddedLangFuncPtr = flag.String("embedded-lang-func", "", "Functions and methods taking a string in another language, comma-separated, as language=function:index,\n"+
+		"e.g. regexp=mypkg.MustMatch:1,graphql=(*mypkg.Client).Query:2. Built-in languages: regexp, regexp-posix,\n"+
+		"template, json, time-layout; the other ones are given with -embedded-lang-binary.")
+	var embeddedLangBinaryPtr = flag.String("embedded-lang-binary", "", "Programs checking the strings of a language, comma-separated, as language=command, e.g. graphql=graphql-lint --stdin:\n"+
+		"the string is given to the standard input, and any output with a nonzero exit code is an error.")
+	var embeddedLangTimeoutPtr = flag.Duration("embedded-lang-timeout", 0, "Timeout of each run of -embedded-lang-binary, e.g. 10s (default: none).")
+	var deprecatedGraceMonthsPtr = flag.Int("deprecated-grace-months", 3, "Number of months after the since date of //!PARANO__DEPRECATED during which its new usages are only warnings.")
+	var deprecatedBaselinePtr = flag.String("deprecated-baseline", "", "JSON file of the known usages of the deprecated declarations, which are only warnings\n"+
+		"(by file, enclosing function and declaration, without line numbers).")
+	var deprecatedBaselineUpdatePtr = flag.Bool("deprecated-baseline-update", false, "If set, writes all the usages of the deprecated declarations found to the file of -deprecated-baseline.")
+	var reportUnusedPtr = flag.Bool("report-unused", false, "Reports private-to-file declarations which are never used in their own file,\n"+
+		"and //!PARANO__ directives which are not attached to any declaration.")
 	flag.Usage = usage
 	flag.Parse()
 	if len(os.Args) == 1 {
@@ -50,20 +98,66 @@ func main() {
 	//---
 	// -sql-query-XXX
 
-	var sqlQueryFunctionsNames = util.NewWildcardMap()
+	var sqlqo = src.SQLQueryOptions{
+		FunctionsNames: util.NewWildcardMap(),
+		AllInOne:       *sqlQueryAllInOnePtr,
+		LintBinary:     *sqlQueryLintBinaryPtr,
+		LintJobs:       *sqlQueryLintJobsPtr,
+		LintTimeout:    *sqlQueryLintTimeoutPtr,
+		LintServer:     *sqlQueryLintServerPtr,
+	}
 	if *sqlQueryFunctionNamePtr != "" {
 		for _, el := range strings.Split(*sqlQueryFunctionNamePtr, ",") {
-			var elSplitted = strings.Split(el, ":")
-			if len(elSplitted) != 2 {
-				userFatalError("Invalid argument: " + *sqlQueryFunctionNamePtr)
-			}
-			var i, err = strconv.Atoi(elSplitted[1])
-			if err != nil {
+			if err := sqlqo.AddFunctionName(el); err != nil {
 				userFatalError("Invalid argument: " + *sqlQueryFunctionNamePtr + ": " + err.Error())
 			}
-			sqlQueryFunctionsNames.Add(elSplitted[0], i)
 		}
 	}
+	if *sqlQueryPresetPtr != "" {
+		for _, preset := range strings.Split(*sqlQueryPresetPtr, ",") {
+			if err := sqlqo.AddPreset(preset); err != nil {
+				userFatalError("Invalid argument: " + *sqlQueryPresetPtr + ": " + err.Error())
+			}
+		}
+	}
+	if *sqlQueryStructFieldPtr != "" {
+		for _, el := range strings.Split(*sqlQueryStructFieldPtr, ",") {
+			if err := sqlqo.AddStructField(el); err != nil {
+				userFatalError("Invalid argument: " + *sqlQueryStructFieldPtr + ": " + err.Error())
+			}
+		}
+	}
+	if *sqlQueryLintBuiltinPtr != "" {
+		var dialect, err = sqlparser.GetDialect(*sqlQueryLintBuiltinPtr)
+		if err != nil {
+			userFatalError("Invalid argument: " + err.Error())
+		}
+		sqlqo.LintDialect = dialect
+		sqlqo.Dialect = dialect
+	}
+	if *sqlQueryDialectPtr != "" {
+		var dialect, err = sqlparser.GetDialect(*sqlQueryDialectPtr)
+		if err != nil {
+			userFatalError("Invalid argument: " + err.Error())
+		}
+		if sqlqo.LintDialect != nil && sqlqo.LintDialect.Name != dialect.Name {
+			userFatalError("Arguments -sql-query-dialect and -sql-query-lint-builtin shall be the same dialect")
+		}
+		sqlqo.Dialect = dialect
+	}
+	if *sqlQuerySchemaPtr != "" {
+		if sqlqo.LintDialect == nil {
+			userFatalError("Argument -sql-query-schema requires -sql-query-lint-builtin")
+		}
+		var schema, err = src.LoadSQLSchema(*sqlQuerySchemaPtr, sqlqo.LintDialect)
+		if err != nil {
+			userFatalError("Invalid argument: -sql-query-schema: " + err.Error())
+		}
+		sqlqo.Schema = schema
+	}
+	if (*sqlQueryFunctionNamePtr != "" || *sqlQueryPresetPtr != "" || *sqlQueryStructFieldPtr != "") && *sqlQueryLintBinaryPtr == "" && *sqlQueryLintBuiltinPtr == "" && !*sqlQueryInjectionPtr && *sqlQueryRulesPtr == "" && *sqlQueryExportPtr == "" {
+		userFatalError("Missing argument -sql-query-lint-binary, -sql-query-lint-builtin, -sql-query-injection, -sql-query-rules or -sql-query-export")
+	}
 	var sqlQueryIgnoreGoFiles = util.NewWildcardMap()
 	if *sqlQueryIgnoreGoFilesPtr != "" {
 		for _, file := range strings.Split(*sqlQueryIgnoreGoFilesPtr, ",") {
@@ -73,11 +167,85 @@ func main() {
 			sqlQueryIgnoreGoFiles.Add(file, nil)
 		}
 	}
-	var sqlqo = src.SQLQueryOptions{
-		FunctionsNames: sqlQueryFunctionsNames,
-		AllInOne:       *sqlQueryAllInOnePtr,
-		LintBinary:     *sqlQueryLintBinaryPtr,
-		IgnoreGoFiles:  sqlQueryIgnoreGoFiles,
+	sqlqo.IgnoreGoFiles = sqlQueryIgnoreGoFiles
+	if *sqlQueryLintBinaryPtr != "" {
+		var fields = strings.Fields(*sqlQueryLintBinaryPtr)
+		if len(fields) == 0 {
+			userFatalError("Invalid argument: -sql-query-lint-binary is empty")
+		}
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			userFatalError("Invalid argument: -sql-query-lint-binary: cannot find the program " + fields[0] + ": " + err.Error())
+		}
+	}
+	if *sqlQueryInjectionPtr {
+		var sanitizers []string
+		if *sqlQuerySanitizersPtr != "" {
+			sanitizers = strings.Split(*sqlQuerySanitizersPtr, ",")
+		}
+		if err := sqlqo.EnableInjectionCheck(sanitizers); err != nil {
+			userFatalError("Invalid argument: -sql-query-sanitizers: " + err.Error())
+		}
+	}
+	if *sqlQueryRulesPtr != "" {
+		if err := sqlqo.EnableRules(strings.Split(*sqlQueryRulesPtr, ",")); err != nil {
+			userFatalError("Invalid argument: -sql-query-rules: " + err.Error())
+		}
+	}
+	if *sqlQueryExportPtr != "" {
+		if err := sqlqo.SetExportFile(*sqlQueryExportPtr); err != nil {
+			userFatalError("Invalid argument: -sql-query-export: " + err.Error())
+		}
+	}
+	sqlqo.SetMigrationDirs(strings.Split(*sqlQueryMigrationDirsPtr, ","))
+	if err := sqlqo.SetHTTPHandlerTypes(strings.Split(*sqlQueryHTTPHandlerTypesPtr, ",")); err != nil {
+		userFatalError("Invalid argument: -sql-query-http-handler-types: " + err.Error())
+	}
+	if *sqlQueryLintBinaryPtr != "" && !*noCachePtr {
+		var cacheDir = *cacheDirPtr
+		if cacheDir == "" {
+			var err error
+			if cacheDir, err = src.DefaultCacheDir(); err != nil {
+				userFatalError("Cannot find the user cache directory, use -cache-dir or -no-cache: " + err.Error())
+			}
+		}
+		if err := sqlqo.SetLintCache(cacheDir, *cacheClearPtr); err != nil {
+			userFatalError("Invalid argument: -cache-dir: " + err.Error())
+		}
+	}
+	if *sqlQueryLintJobsPtr < 1 {
+		userFatalError("Invalid argument: -sql-query-lint-jobs shall be at least 1")
+	}
+	if err := sqlqo.SetLintFormat(*sqlQueryLintFormatPtr); err != nil {
+		userFatalError("Invalid argument: -sql-query-lint-format: " + err.Error())
+	}
+	sqlqo.IgnoredRules = util.NewWildcardMap()
+	if *sqlQueryLintIgnoreRulesPtr != "" {
+		for _, rule := range strings.Split(*sqlQueryLintIgnoreRulesPtr, ",") {
+			sqlqo.IgnoredRules.Add(rule, nil)
+		}
+	}
+
+	//---
+	// -embedded-lang-XXX
+
+	var elo = src.NewEmbeddedLangOptions()
+	elo.Timeout = *embeddedLangTimeoutPtr
+	if *embeddedLangBinaryPtr != "" {
+		for _, el := range strings.Split(*embeddedLangBinaryPtr, ",") {
+			if err := elo.AddBinary(el); err != nil {
+				userFatalError("Invalid argument: -embedded-lang-binary: " + err.Error())
+			}
+		}
+	}
+	if *embeddedLangBuiltinPtr {
+		elo.AddBuiltinFunctions()
+	}
+	if *embeddedLangFuncPtr != "" {
+		for _, el := range strings.Split(*embeddedLangFuncPtr, ",") {
+			if err := elo.AddFunction(el); err != nil {
+				userFatalError("Invalid argument: -embedded-lang-func: " + err.Error())
+			}
+		}
 	}
 
 	//---
@@ -101,6 +269,28 @@ func main() {
 		ignorePrivateToFile.Add(name, nil)
 	}
 
+	//---
+	// -immutable-allowed-funcs
+
+	var immutableAllowedFuncs = util.NewWildcardMap()
+	if *immutableAllowedFuncsPtr != "" {
+		for _, name := range strings.Split(*immutableAllowedFuncsPtr, ",") {
+			immutableAllowedFuncs.Add(name, nil)
+		}
+	}
+
+	//---
+	// -deprecated-XXX
+
+	var do = src.DeprecatedOptions{GraceMonths: *deprecatedGraceMonthsPtr}
+	if *deprecatedBaselinePtr != "" {
+		if err := do.SetBaselineFile(*deprecatedBaselinePtr, *deprecatedBaselineUpdatePtr); err != nil {
+			userFatalError("Invalid argument: -deprecated-baseline: " + err.Error())
+		}
+	} else if *deprecatedBaselineUpdatePtr {
+		userFatalError("Missing argument -deprecated-baseline")
+	}
+
 	//---
 	// -dir / -pkg
 
@@ -120,10 +310,20 @@ func main() {
 	// do stuff
 
 	src.DoAll(*pkgDirPtr, src.Options{
-		IgnoreGoFiles:       ignoreGoFiles,
-		IgnorePrivateToFile: ignorePrivateToFile,
-		Sqlqo:               sqlqo,
+		IgnoreGoFiles:         ignoreGoFiles,
+		IgnorePrivateToFile:   ignorePrivateToFile,
+		ReportUnused:          *reportUnusedPtr,
+		ImmutableAllowedFuncs: immutableAllowedFuncs,
+		Sqlqo:                 sqlqo,
+		Elo:                   elo,
+		Do:                    do,
 	})
+	if err := src.WriteSQLQueryExport(sqlqo); err != nil {
+		userFatalError("Cannot write -sql-query-export: " + err.Error())
+	}
+	if err := src.WriteDeprecatedBaseline(do); err != nil {
+		userFatalError("Cannot write -deprecated-baseline: " + err.Error())
+	}
 
 	os.Exit(util.GetExitCode())
 }
@@ -146,7 +346,7 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "Arguments:\n")
 	flag.PrintDefaults()
 	fmt.Printf("Note:\n" +
-		"  Options -ignore-go-files,-ignore-private-to-file,-sql-query-func-name,-sql-query-ignore-go-files \n" +
+		"  Options -ignore-go-files,-ignore-private-to-file,-immutable-allowed-funcs,-sql-query-func-name,-sql-query-ignore-go-files \n" +
 		"  accept up to one one wilcard character '*' by element.\n")
 	os.Exit(1)
 }
@@ -15,39 +15,56 @@ type packageInfos struct {
 	packageDir       string
 	subPackagesInfos []*packageInfos
 	infosByFile      map[string]infosFile
+	symbols          *symbolIndex
 }
 
 type infosFile struct {
 	packageName              string
 	rootNode                 *fileparser.Node
-	fileConstants            []fileparser.ConstValue
+	comments                 []fileparser.Comment
+	imports                  map[string]string
 	featurePrivateToFile     *featurePrivateToFile
 	featureExhaustiveFilling *featureExhaustiveFilling
+	featureImmutable         *featureImmutable
+	featureMustCheckError    *featureMustCheckError
+	featureMustUse           *featureMustUse
+	featureDeprecated        *featureDeprecated
+	featureGuardedBy         *featureGuardedBy
 }
 
 // Options defines options for checks
 type Options struct {
-	IgnoreGoFiles       util.WildcardMap
-	IgnorePrivateToFile util.WildcardMap
-	Sqlqo               SQLQueryOptions
+	IgnoreGoFiles         util.WildcardMap
+	IgnorePrivateToFile   util.WildcardMap
+	ReportUnused          bool
+	ImmutableAllowedFuncs util.WildcardMap
+	Sqlqo                 SQLQueryOptions
+	Elo                   EmbeddedLangOptions
+	Do                    DeprecatedOptions
 }
 
 //------------------------------------------------------------------------------
 
 func DoAll(pkgDir string, options Options) {
 
-	var rootPkg = recurseDir(pkgDir, options)
+	options.Do.rootDir = pkgDir
 
-	ParanoSqllintCheckQueries(options.Sqlqo)
+	var rootPkg = recurseDir(pkgDir, options)
 
 	var mInfosByPackageName = make(map[string]*packageInfos)
 	processPkgRecursiveAndMakeMap(rootPkg, mInfosByPackageName)
 
+	checkPkgRecursive(rootPkg, mInfosByPackageName, options)
+
+	ParanoSqllintCheckQueries(options.Sqlqo)
+
 	if util.IsInfo() {
 		util.Info("\"Fourth\" pass")
 	}
 
-	processPkgAgain(mInfosByPackageName)
+	processPkgAgain(mInfosByPackageName, options)
+
+	ReportUnusedSuppressions()
 }
 
 //------------------------------------------------------------------------------
@@ -61,6 +78,18 @@ func processPkgRecursiveAndMakeMap(pkgInfos *packageInfos, mInfosByPackageName m
 
 //------------------------------------------------------------------------------
 
+func checkPkgRecursive(pkgInfos *packageInfos, mInfosByPackageName map[string]*packageInfos, options Options) {
+	for _, subPackageInfos := range pkgInfos.subPackagesInfos {
+		checkPkgRecursive(subPackageInfos, mInfosByPackageName, options)
+	}
+	if util.IsInfo() {
+		util.Info("Checking package: %s", pkgInfos.packageDir)
+	}
+	processPkgFiles(pkgInfos, mInfosByPackageName, options)
+}
+
+//------------------------------------------------------------------------------
+
 func recurseDir(pkgDir string, options Options) *packageInfos {
 
 	var subPackagesInfos = make([]*packageInfos, 0)
@@ -90,7 +119,21 @@ func recurseDir(pkgDir string, options Options) *packageInfos {
 		util.Info("Processing package: %s", pkgDir)
 	}
 
-	var infosByFile = processPkgFiles(srcFiles, options)
+	var infosByFile = make(map[string]infosFile)
+	for _, filename := range srcFiles {
+		if _, ok := options.IgnoreGoFiles.Find(filename); ok {
+			if util.IsDebug() || util.IsInfo() {
+				util.Info("  Ignoring: %s", filename)
+			}
+		} else {
+			infosByFile[filename] = processFile(filename)
+		}
+	}
+
+	for filename, fileInfos := range infosByFile {
+		registerSuppressions(filename, fileInfos)
+	}
+
 	var packageName string // note: remains empty string if no source files
 	for _, infosFile := range infosByFile {
 		packageName = infosFile.packageName
@@ -101,58 +144,51 @@ func recurseDir(pkgDir string, options Options) *packageInfos {
 		packageDir:       pkgDir,
 		subPackagesInfos: subPackagesInfos,
 		infosByFile:      infosByFile,
+		symbols:          newSymbolIndex(infosByFile),
 	}
 }
 
 //------------------------------------------------------------------------------
 
-func processPkgFiles(files []string, options Options) (infosByFile map[string]infosFile) {
-
-	infosByFile = make(map[string]infosFile)
-	for _, filename := range files {
-		if _, ok := options.IgnoreGoFiles.Find(filename); ok {
-			if util.IsDebug() || util.IsInfo() {
-				util.Info("  Ignoring: %s", filename)
-			}
-		} else {
-			infosByFile[filename] = processFile(filename)
-		}
-	}
-
-	if util.IsDebug() || util.IsInfo() {
-		util.Info("  Checking ...")
-	}
+func processPkgFiles(pkgInfos *packageInfos, mInfosByPackageName map[string]*packageInfos, options Options) {
 
-	var allConstants []fileparser.ConstValue
-	for _, fileInfos := range infosByFile { // for each input file
-		allConstants = append(allConstants, fileInfos.fileConstants...)
-		// TODO get constants declared in another package?
-	}
+	var infosByFile = pkgInfos.infosByFile
+	var symbols = pkgInfos.symbols
 
 	//----
 	// third pass => check
 
 	for filename1, fileInfos := range infosByFile { // for each input file
+		var tr = newTypeResolver(mInfosByPackageName, pkgInfos, filename1)
 		fileInfos.rootNode.Visit(func(n *fileparser.Node) {
-			if options.Sqlqo.FunctionsNames.Count() > 0 {
+			if options.Sqlqo.isEnabled() {
 				if _, ok := options.Sqlqo.IgnoreGoFiles.Find(filename1); ok {
 					if util.IsDebug() || util.IsInfo() {
 						util.Info("  Ignoring: %s", filename1)
 					}
 				} else {
-					ParanoSqllintVisit(n, filename1, allConstants, options.Sqlqo)
+					ParanoSqllintVisit(n, filename1, tr, options.Sqlqo)
 				}
 			}
+			if options.Elo.isEnabled() {
+				ParanoEmbeddedLangVisit(n, filename1, tr, options.Elo)
+			}
 			if n.Name != "" {
-				for filename2, fileInfos2 := range infosByFile { // for each file
-					ParanoPrivateToFileCheck(n, fileInfos2.featurePrivateToFile, filename1, filename2, options.IgnorePrivateToFile)
-					ParanoExhaustiveFillingCheck(n, fileInfos2.packageName, fileInfos2.featureExhaustiveFilling, filename1, filename2)
+				for _, symbol := range symbols.lookup(n.Name) {
+					ParanoPrivateToFileCheck(n, symbol, filename1, options.IgnorePrivateToFile)
+					ParanoExhaustiveFillingCheck(n, symbol, filename1)
+					ParanoImmutableCheck(n, symbol, filename1, options.ImmutableAllowedFuncs)
 				}
 			}
 		})
 	}
 
-	return
+	for filename, fileInfos := range infosByFile {
+		ParanoDirectivesCheck(filename, fileInfos, options.ReportUnused)
+		if options.ReportUnused {
+			ParanoUnusedCheck(filename, fileInfos)
+		}
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -175,6 +211,11 @@ func processFile(filename string) infosFile {
 
 	var featurePrivateToFile = ParanoPrivateToFileInit(fileInfo.FileBuffer)
 	var featureExhaustiveFilling = ParanoExhaustiveFillingInit()
+	var featureImmutable = ParanoImmutableInit()
+	var featureMustCheckError = ParanoMustCheckErrorInit()
+	var featureMustUse = ParanoMustUseInit()
+	var featureDeprecated = ParanoDeprecatedInit()
+	var featureGuardedBy = ParanoGuardedByInit()
 
 	//----
 	// second pass => gather informations about nodes of this file
@@ -185,14 +226,25 @@ func processFile(filename string) infosFile {
 		}
 		ParanoPrivateToFileVisit(n, featurePrivateToFile)
 		ParanoExhaustiveFillingVisit(n, featureExhaustiveFilling)
+		ParanoImmutableVisit(n, featureImmutable)
+		ParanoMustCheckErrorVisit(n, featureMustCheckError)
+		ParanoMustUseVisit(n, featureMustUse)
+		ParanoDeprecatedVisit(n, featureDeprecated)
+		ParanoGuardedByVisit(n, featureGuardedBy)
 	})
 
 	var infosf = infosFile{
 		packageName:              fileInfo.PackageName,
 		rootNode:                 fileInfo.RootNode,
-		fileConstants:            fileInfo.FileConstants,
+		comments:                 fileInfo.Comments,
+		imports:                  fileInfo.Imports,
 		featurePrivateToFile:     featurePrivateToFile,
 		featureExhaustiveFilling: featureExhaustiveFilling,
+		featureImmutable:         featureImmutable,
+		featureMustCheckError:    featureMustCheckError,
+		featureMustUse:           featureMustUse,
+		featureDeprecated:        featureDeprecated,
+		featureGuardedBy:         featureGuardedBy,
 	}
 
 	if util.IsDebug() {
@@ -204,12 +256,17 @@ func processFile(filename string) infosFile {
 
 //------------------------------------------------------------------------------
 
-func processPkgAgain(mInfosByPackageName map[string]*packageInfos) {
+func processPkgAgain(mInfosByPackageName map[string]*packageInfos, options Options) {
 
 	//----
 	// fourth pass => check
 
 	ParanoExhaustiveFillingCheckGlobal(mInfosByPackageName)
+	ParanoImmutableCheckGlobal(mInfosByPackageName, options.ImmutableAllowedFuncs)
+	ParanoMustCheckErrorCheckGlobal(mInfosByPackageName)
+	ParanoMustUseCheckGlobal(mInfosByPackageName)
+	ParanoDeprecatedCheckGlobal(mInfosByPackageName, options.Do)
+	ParanoGuardedByCheckGlobal(mInfosByPackageName)
 }
 
 //------------------------------------------------------------------------------
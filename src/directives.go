@@ -0,0 +1,232 @@
+package src
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constDirectivePrefix = "//!PARANO__"
+
+// what each directive can be attached to, by directive name
+var knownDirectives = map[string][]string{
+	constPrivateToFileDirective:          {"func", "method", "type", "var"},
+	constExhaustiveFillingDirective:      {"type"},
+	constImmutableDirective:              {"var"},
+	constMustCheckErrorDirective:         {"func", "method"},
+	constMustUseDirective:                {"func", "method", "type"},
+	constDeprecatedDirective:             {"func", "method", "type", "const", "field"},
+	constGuardedByDirective:              {"field"},
+	constRequiresLockDirective:           {"func", "method"},
+	constIgnoreGoCheckDBQueriesDirective: {"func", "method"},
+	constIgnoreGoCheckDBQueryDirective:   {"call"},
+	constIgnoreDirective:                 {"func", "method", "type", "var", "const", "field", "call", "statement", "file", "line"},
+}
+
+//------------------------------------------------------------------------------
+
+type directiveTarget struct {
+	// "func", "method", "type", "var", "const", "field", "call" (comment inside a function call),
+	// "statement" (comment on top of a statement), "file" (on top of the package clause),
+	// or "line" (comment after some code on the same line)
+	kind string
+	node *fileparser.Node // node the comment is attached to, nil for "line"
+	line int              // line of the comment for "line", 0 otherwise
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoDirectivesCheck reports the //!PARANO__ directives of a file which are unknown, attached to something
+// they do not support, or duplicated; and if reportStale is set, the ones which are not attached to anything.
+func ParanoDirectivesCheck(filename string, fileInfos infosFile, reportStale bool) {
+
+	var targets = directiveTargets(fileInfos.rootNode, fileInfos.comments)
+	var alreadySeen = make(map[directiveTarget]map[string]bool)
+
+	for _, comment := range fileInfos.comments {
+		var d, ok = fileparser.ParseDirective(comment.Text)
+		if !ok {
+			continue
+		}
+		var pos = commentPosition(filename, comment)
+		var allowedKinds, known = knownDirectives[d.Name]
+		if !known {
+			var suggestion = suggestDirective(d.Name)
+			if suggestion != "" {
+				notPass(constCheckIDDirective, pos, "Unknown directive %s in %s:%d, did you mean %s?", comment.Text, filename, comment.Line, constDirectivePrefix+suggestion)
+			} else {
+				notPass(constCheckIDDirective, pos, "Unknown directive %s in %s:%d", comment.Text, filename, comment.Line)
+			}
+			continue
+		}
+		var target, attached = targets[comment.BytesIndexBegin]
+		if !attached {
+			if reportStale {
+				notPass(constCheckIDDirective, pos, "Directive %s in %s:%d is not attached to any declaration", comment.Text, filename, comment.Line)
+			}
+			continue
+		}
+		var supported = false
+		for _, kind := range allowedKinds {
+			if kind == target.kind {
+				supported = true
+			}
+		}
+		if !supported {
+			notPass(constCheckIDDirective, pos, "Directive %s in %s:%d cannot be used on a %s (only on: %s)",
+				comment.Text, filename, comment.Line, target.kind, strings.Join(allowedKinds, ", "))
+			continue
+		}
+		if alreadySeen[target] == nil {
+			alreadySeen[target] = make(map[string]bool)
+		}
+		var key = strings.Join(append([]string{d.Name}, d.Args...), " ")
+		if alreadySeen[target][key] {
+			notPass(constCheckIDDirective, pos, "Duplicate directive %s in %s:%d", comment.Text, filename, comment.Line)
+		}
+		alreadySeen[target][key] = true
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// directiveTargets returns what each comment of the file is attached to (by position of the comment),
+// for those attached to something.
+func directiveTargets(rootNode *fileparser.Node, comments []fileparser.Comment) map[int]directiveTarget {
+
+	var targets = make(map[int]directiveTarget)
+	var callExprs []*fileparser.Node
+	var outermostNodeByBegin = make(map[int]*fileparser.Node)
+
+	rootNode.Visit(func(n *fileparser.Node) {
+		if n.TypeStr == "CallExpr" {
+			callExprs = append(callExprs, n)
+		}
+		if n.Father != nil && n.TypeStr != "CommentGroup" && n.TypeStr != "Comment" {
+			if _, ok := outermostNodeByBegin[n.BytesIndexBegin]; !ok {
+				outermostNodeByBegin[n.BytesIndexBegin] = n
+			}
+		}
+		if n.TypeStr != "CommentGroup" || n.Father == nil {
+			return
+		}
+		var target = directiveTarget{node: n.Father}
+		switch n.Father.TypeStr {
+		case "FuncDecl":
+			target.kind = "func"
+			if n.Father.IsMethod() {
+				target.kind = "method"
+			}
+		case "GenDecl": // only var declarations have children, see the file parser
+			target.kind = "var"
+		case "TypeSpec":
+			target.kind = "type"
+		case "ValueSpec":
+			target.kind = valueSpecKind(n.Father)
+		case "Field":
+			target.kind = "field"
+		default:
+			var nextNode = n.NextNode()
+			if nextNode != nil && nextNode.TypeStr == "TypeSpec" {
+				target = directiveTarget{kind: "type", node: nextNode}
+			} else if nextNode != nil && nextNode.TypeStr == "ValueSpec" {
+				target = directiveTarget{kind: valueSpecKind(nextNode), node: nextNode}
+			}
+		}
+		if target.kind != "" {
+			for _, comment := range n.Children {
+				targets[comment.BytesIndexBegin] = target
+			}
+		}
+	})
+
+	for _, comment := range comments {
+		if _, ok := targets[comment.BytesIndexBegin]; !ok {
+			var nInnermostCall *fileparser.Node
+			for _, nCall := range callExprs {
+				if comment.BytesIndexBegin > nCall.BytesIndexBegin && comment.BytesIndexEnd < nCall.BytesIndexEnd {
+					if nInnermostCall == nil || nCall.BytesIndexEnd-nCall.BytesIndexBegin < nInnermostCall.BytesIndexEnd-nInnermostCall.BytesIndexBegin {
+						nInnermostCall = nCall
+					}
+				}
+			}
+			if nInnermostCall != nil {
+				targets[comment.BytesIndexBegin] = directiveTarget{kind: "call", node: nInnermostCall}
+			}
+		}
+	}
+
+	//----
+	// comments within the code: attached to the line if there is some code before,
+	// or to the statement on the next line
+
+	var begins = make([]int, 0, len(outermostNodeByBegin))
+	for begin := range outermostNodeByBegin {
+		begins = append(begins, begin)
+	}
+	sort.Ints(begins)
+
+	for i, comment := range comments {
+		if _, ok := targets[comment.BytesIndexBegin]; ok {
+			continue
+		}
+		if comment.IsTrailing {
+			targets[comment.BytesIndexBegin] = directiveTarget{kind: "line", line: comment.Line}
+			continue
+		}
+		var lastLine = comment.Line // last line of this block of comments
+		for j := i + 1; j < len(comments) && comments[j].Line == lastLine+1 && !comments[j].IsTrailing; j++ {
+			lastLine++
+		}
+		var k = sort.SearchInts(begins, comment.BytesIndexEnd)
+		if k < len(begins) {
+			var nNext = outermostNodeByBegin[begins[k]]
+			if nNext.Line == lastLine+1 {
+				if nNext.TypeStr == "File" {
+					targets[comment.BytesIndexBegin] = directiveTarget{kind: "file", node: nNext}
+				} else {
+					targets[comment.BytesIndexBegin] = directiveTarget{kind: "statement", node: nNext}
+				}
+			}
+		}
+	}
+
+	return targets
+}
+
+//------------------------------------------------------------------------------
+
+// valueSpecKind returns "var" or "const" for a ValueSpec node.
+func valueSpecKind(n *fileparser.Node) string {
+	if n.Father != nil && n.Father.TypeStr == "GenDecl" { // const declarations are flattened by the file parser
+		return "var"
+	}
+	return "const"
+}
+
+//------------------------------------------------------------------------------
+
+// suggestDirective returns the known directive name closest to this unknown one, or "" if none is close enough.
+func suggestDirective(name string) string {
+	var names = make([]string, 0, len(knownDirectives))
+	for knownName := range knownDirectives {
+		names = append(names, knownName)
+	}
+	sort.Strings(names)
+	var best = ""
+	var bestDistance = 4 // maximum distance + 1
+	for _, knownName := range names {
+		var distance = util.LevenshteinDistance(strings.ToUpper(name), knownName)
+		if distance < bestDistance {
+			best = knownName
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+//------------------------------------------------------------------------------
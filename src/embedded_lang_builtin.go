@@ -0,0 +1,126 @@
+package src
+
+import (
+	"encoding/json"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// constReferenceTime is the time whose elements are written in the layouts of Go.
+const constReferenceTime = "Mon Jan 2 15:04:05 MST 2006"
+
+// builtinEmbeddedLanguages are the validators of the built-in languages, by name.
+var builtinEmbeddedLanguages = map[string]func(text string, timeout time.Duration) []embeddedLangError{
+	"regexp":       validateRegexp,
+	"regexp-posix": validateRegexpPOSIX,
+	"template":     validateTemplate,
+	"json":         validateJSON,
+	"time-layout":  validateTimeLayout,
+}
+
+// builtinEmbeddedLangFunctions are the functions of the standard library added by -embedded-lang-builtin.
+var builtinEmbeddedLangFunctions = []string{
+	"regexp=regexp.Compile:1", "regexp=regexp.MustCompile:1", "regexp=regexp.MatchString:1", "regexp=regexp.Match:1",
+	"regexp-posix=regexp.CompilePOSIX:1", "regexp-posix=regexp.MustCompilePOSIX:1",
+	"template=(*text/template.Template).Parse:1", "template=(*html/template.Template).Parse:1",
+	"json=encoding/json.Unmarshal:1",
+	"time-layout=time.Parse:1", "time-layout=time.ParseInLocation:1", "time-layout=(time.Time).Format:1",
+	"time-layout=(time.Time).AppendFormat:2",
+}
+
+// regexpTemplateUndefinedFunction matches the error of a template calling a function which is not defined,
+// e.g. `template: x:1: function "upper" not defined`.
+var regexpTemplateUndefinedFunction = regexp.MustCompile(`function "(\w+)" not defined`)
+
+// regexpTemplateErrorLine matches the line of an error of a template, e.g. `template: x:3: unexpected "}"`.
+var regexpTemplateErrorLine = regexp.MustCompile(`^template: [^:]*:(\d+):`)
+
+// regexpForeignTimeLayout matches the elements of the layouts of other languages (strftime, Java, moment.js, ...)
+// which are not elements of the layouts of Go, e.g. "YYYY-MM-DD" or "%Y-%m-%d".
+var regexpForeignTimeLayout = regexp.MustCompile(`(?:^|[^A-Za-z])(YYYY|yyyy|YY|yy|DD|dd|HH|hh|MM|mm|SS|ss)(?:[^A-Za-z]|$)|(%[a-zA-Z])`)
+
+//------------------------------------------------------------------------------
+
+func validateRegexp(text string, timeout time.Duration) []embeddedLangError {
+	var _, err = regexp.Compile(text)
+	return regexpErrors(text, err)
+}
+
+func validateRegexpPOSIX(text string, timeout time.Duration) []embeddedLangError {
+	var _, err = regexp.CompilePOSIX(text)
+	return regexpErrors(text, err)
+}
+
+// regexpErrors returns the error of the compilation of a regular expression, at the position of the
+// invalid part of the expression if it is found.
+func regexpErrors(text string, err error) []embeddedLangError {
+	if err == nil {
+		return nil
+	}
+	var offset = -1
+	if syntaxErr, ok := err.(*syntax.Error); ok && syntaxErr.Expr != "" {
+		offset = strings.Index(text, syntaxErr.Expr)
+	}
+	return []embeddedLangError{{offset: offset, message: err.Error()}}
+}
+
+// validateTemplate parses a template of text/template (the syntax of html/template is the same): the functions
+// are unknown, so any function is accepted.
+func validateTemplate(text string, timeout time.Duration) []embeddedLangError {
+	var funcs = template.FuncMap{}
+	for {
+		var _, err = template.New("").Funcs(funcs).Parse(text)
+		if err == nil {
+			return nil
+		}
+		if matches := regexpTemplateUndefinedFunction.FindStringSubmatch(err.Error()); matches != nil && funcs[matches[1]] == nil {
+			funcs[matches[1]] = func(args ...interface{}) interface{} { return nil }
+			continue
+		}
+		var offset = -1
+		if matches := regexpTemplateErrorLine.FindStringSubmatch(err.Error()); matches != nil {
+			var line, _ = strconv.Atoi(matches[1])
+			offset = lineColumnToOffset(text, line, 1)
+		}
+		return []embeddedLangError{{offset: offset, message: err.Error()}}
+	}
+}
+
+func validateJSON(text string, timeout time.Duration) []embeddedLangError {
+	var value interface{}
+	var err = json.Unmarshal([]byte(text), &value)
+	if err == nil {
+		return nil
+	}
+	var offset = -1
+	if syntaxErr, ok := err.(*json.SyntaxError); ok && syntaxErr.Offset > 0 {
+		offset = int(syntaxErr.Offset) - 1 // the offset is after the invalid character
+	}
+	return []embeddedLangError{{offset: offset, message: err.Error()}}
+}
+
+// validateTimeLayout checks a layout of time.Parse() or time.Format(): it shall contain at least an element
+// of the reference time (e.g. "2006" or "15"), and no element of the layouts of other languages (e.g. "YYYY").
+func validateTimeLayout(text string, timeout time.Duration) []embeddedLangError {
+	if matches := regexpForeignTimeLayout.FindStringSubmatchIndex(text); matches != nil {
+		var begin, end = matches[2], matches[3]
+		if begin == -1 {
+			begin, end = matches[4], matches[5]
+		}
+		return []embeddedLangError{{offset: begin, message: "\"" + text[begin:end] +
+			"\" is not an element of a Go layout, which is written with the reference time " + constReferenceTime}}
+	}
+	var reference = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if reference.Format(text) == text {
+		return []embeddedLangError{{offset: -1, message: "no element of the reference time " + constReferenceTime + " in this layout"}}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
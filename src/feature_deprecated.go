@@ -0,0 +1,407 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constDeprecatedDirective = "DEPRECATED"
+
+// constDeprecatedDocPrefix begins the paragraph of a standard doc comment marking a deprecated declaration.
+const constDeprecatedDocPrefix = "Deprecated:"
+
+//------------------------------------------------------------------------------
+
+// DeprecatedOptions are the options of the usages of the deprecated declarations.
+type DeprecatedOptions struct {
+	GraceMonths    int    // after the since date, the new usages are reported as errors once this period is over
+	BaselineFile   string // known usages, which are only warnings, "" if none
+	UpdateBaseline bool   // if true, BaselineFile is written with the current usages by WriteDeprecatedBaseline
+	baseline       map[deprecatedUsage]int
+	rootDir        string // scanned directory, the files of the baseline are relative to it
+}
+
+// deprecation is a declaration marked with //!PARANO__DEPRECATED or with a "// Deprecated:" doc comment.
+type deprecation struct {
+	use      string           // suggested replacement, "" if none
+	since    string           // "YYYY-MM", "" if unknown
+	message  string           // paragraph of the "// Deprecated:" doc comment, "" if none
+	nComment *fileparser.Node // CommentGroup
+}
+
+// deprecatedUsage is a usage of a deprecated declaration, as written in the baseline (without line
+// numbers, so that the baseline remains valid when the code is modified around).
+type deprecatedUsage struct {
+	File     string `json:"file"`     // relative to the scanned directory, e.g. "mypkg/store.go"
+	Function string `json:"function"` // enclosing function, e.g. "mypkg.Store.Foo", "" if none
+	Symbol   string `json:"symbol"`   // e.g. "mypkg.OldFunc" or "mypkg.Config.OldField"
+}
+
+type deprecatedBaselineEntry struct {
+	deprecatedUsage
+	Count int `json:"count"`
+}
+
+var deprecatedUsages = make(map[deprecatedUsage]int)
+
+//------------------------------------------------------------------------------
+
+type featureDeprecated struct {
+	deprecatedDecl map[string]*deprecation // by name, "Type.Method" or "Type.Field" for a method or a field
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoDeprecatedInit() *featureDeprecated {
+	return &featureDeprecated{
+		deprecatedDecl: make(map[string]*deprecation),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoDeprecatedVisit(n *fileparser.Node, feat *featureDeprecated) {
+
+	if n.TypeStr != "CommentGroup" || n.Father == nil {
+		return
+	}
+	var d = &deprecation{nComment: n}
+	if directive := n.GetCommentGroupDirective(constDeprecatedDirective); directive != nil {
+		d.use, d.since = directive.Options["use"], directive.Options["since"]
+	} else if d.message = getDeprecatedDocParagraph(n); d.message == "" {
+		return
+	}
+	for _, name := range getDeprecatedDeclNames(n) {
+		if util.IsDebug() {
+			util.DebugPrintf("....... Deprecated: >= %s <=", name)
+		}
+		feat.deprecatedDecl[name] = d
+	}
+}
+
+// getDeprecatedDeclNames returns the names of what a comment is attached to, among functions, methods
+// ("Type.Method"), types, constants, and fields ("Type.Field") or methods of an interface.
+func getDeprecatedDeclNames(nComment *fileparser.Node) (names []string) {
+	var nDecl = nComment.Father
+	if nDecl.TypeStr != "FuncDecl" && nDecl.TypeStr != "TypeSpec" && nDecl.TypeStr != "ValueSpec" && nDecl.TypeStr != "Field" {
+		nDecl = nComment.NextNode()
+		if nDecl == nil || (nDecl.TypeStr != "TypeSpec" && nDecl.TypeStr != "ValueSpec") {
+			return
+		}
+	}
+	switch nDecl.TypeStr {
+	case "FuncDecl":
+		if nDecl.IsMethod() {
+			return []string{nDecl.ReceiverTypeName() + "." + nDecl.Name}
+		}
+		return []string{nDecl.Name}
+	case "TypeSpec":
+		return []string{nDecl.Name}
+	case "ValueSpec":
+		if valueSpecKind(nDecl) == "const" {
+			return nDecl.DeclaredNames()
+		}
+	case "Field":
+		return getFieldDeclNames(nDecl)
+	}
+	return
+}
+
+// getFieldDeclNames returns the names of a Field in "type T struct {...}" or "type T interface {...}",
+// as "T.Field", or nil if this is another kind of Field (e.g. a parameter).
+func getFieldDeclNames(nField *fileparser.Node) (names []string) {
+	var nFieldList = nField.Father
+	if nFieldList == nil || nFieldList.Father == nil || nFieldList.Father.Father == nil || nFieldList.Father.Father.TypeStr != "TypeSpec" {
+		return
+	}
+	for _, name := range nField.DeclaredNames() {
+		names = append(names, nFieldList.Father.Father.Name+"."+name)
+	}
+	return
+}
+
+// getDeprecatedDocParagraph returns the paragraph of a doc comment beginning with "Deprecated:", e.g.
+// "Deprecated: use NewThing instead.", or "" if there is none.
+func getDeprecatedDocParagraph(nComment *fileparser.Node) string {
+	var lines []string
+	for _, nLine := range nComment.Children {
+		var text = nLine.Bytes
+		if strings.HasPrefix(text, "/*") {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		} else {
+			text = strings.TrimPrefix(text, "//")
+		}
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	var paragraph []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(paragraph) == 0 {
+			if strings.HasPrefix(line, constDeprecatedDocPrefix) {
+				paragraph = append(paragraph, line)
+			}
+		} else if line == "" {
+			break
+		} else {
+			paragraph = append(paragraph, line)
+		}
+	}
+	return strings.Join(paragraph, " ")
+}
+
+//------------------------------------------------------------------------------
+
+// SetBaselineFile sets the file of the known usages: it is read, unless it is updated (and then it may not exist).
+func (do *DeprecatedOptions) SetBaselineFile(path string, update bool) error {
+	do.BaselineFile, do.UpdateBaseline = path, update
+	do.baseline = make(map[deprecatedUsage]int)
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		if update && os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var entries []deprecatedBaselineEntry
+	if err = json.Unmarshal(content, &entries); err != nil {
+		return fmt.Errorf("cannot read %s: %s", path, err.Error())
+	}
+	for _, entry := range entries {
+		do.baseline[entry.deprecatedUsage] += entry.Count
+	}
+	return nil
+}
+
+// WriteDeprecatedBaseline writes the usages of the deprecated declarations found to the baseline file,
+// if it shall be updated.
+func WriteDeprecatedBaseline(do DeprecatedOptions) error {
+	if !do.UpdateBaseline {
+		return nil
+	}
+	var entries = []deprecatedBaselineEntry{} // "[]" rather than "null"
+	for usage, count := range deprecatedUsages {
+		entries = append(entries, deprecatedBaselineEntry{deprecatedUsage: usage, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		var a, b = entries[i], entries[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Function != b.Function {
+			return a.Function < b.Function
+		}
+		return a.Symbol < b.Symbol
+	})
+	var content, err = json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(do.BaselineFile, append(content, '\n'), 0644)
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoDeprecatedCheckGlobal reports the references, from other files or packages, to the deprecated
+// declarations: as warnings until the grace period after their since date is over, and then as errors
+// unless they are in the baseline.
+func ParanoDeprecatedCheckGlobal(mInfosByPackageName map[string]*packageInfos, do DeprecatedOptions) (failedAtLeastOnce bool) {
+
+	var checkedSince = make(map[*deprecation]bool) // a comment may be on several constants or fields
+	for _, packageInfos := range mInfosByPackageName {
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if d := symbol.deprecation; d != nil && d.since != "" && !checkedSince[d] {
+				checkedSince[d] = true
+				if _, err := parseDeprecatedSince(d.since); err != nil {
+					notPass(constCheckIDDirective, nodePosition(symbol.filename, d.nComment), "Directive %s on %s in %s:%d: invalid date since=%s, expected YYYY-MM",
+						constDirectivePrefix+constDeprecatedDirective, symbol.name, symbol.filename, d.nComment.Line, d.since)
+				}
+			}
+		})
+	}
+
+	for _, packageInfos := range mInfosByPackageName {
+		for filename, fileInfos := range packageInfos.infosByFile {
+			var tr = newTypeResolver(mInfosByPackageName, packageInfos, filename)
+			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+				var symbol, qualifiedName = findDeprecatedSymbol(n, tr)
+				if symbol == nil || symbol.filename == filename {
+					return
+				}
+				if checkDeprecatedUsage(n, symbol, qualifiedName, filename, packageInfos.packageName, do) {
+					failedAtLeastOnce = true
+				}
+			})
+		}
+	}
+	return
+}
+
+// findDeprecatedSymbol returns the deprecated declaration referenced by n, with its name qualified by its
+// package (e.g. "mypkg.Config.OldField"), or nil.
+func findDeprecatedSymbol(n *fileparser.Node, tr *typeResolver) (*symbolInfo, string) {
+
+	switch n.TypeStr {
+
+	case "Ident": // OldFunc, OldType, OldConst, or OldField in "Config{OldField: 1}"
+		var nFather = n.Father
+		if nFather == nil || (nFather.TypeStr == "SelectorExpr" && n.Index == 1) || nFather.TypeStr == "FuncDecl" ||
+			((nFather.TypeStr == "TypeSpec" || nFather.TypeStr == "ValueSpec" || nFather.TypeStr == "Field") && n.Index < len(nFather.DeclaredNames())) {
+			return nil, "" // this is a declaration, or the field or the method of something else
+		}
+		if nFather.TypeStr == "KeyValueExpr" && n.Index == 0 && nFather.Father != nil && nFather.Father.TypeStr == "CompositeLit" {
+			if t, ok := tr.compositeLitType(nFather.Father); ok {
+				if symbol, name := findDeprecatedMember(tr, t, n.Name); symbol != nil {
+					return symbol, name
+				}
+			}
+		}
+		if n.FindLocalDeclaration(n.Name) != nil {
+			return nil, ""
+		}
+		for _, symbol := range tr.pkg.symbols.lookup(n.Name) {
+			if symbol.deprecation != nil && (symbol.kind == "func" || symbol.kind == "type" || symbol.kind == "const") {
+				return symbol, tr.pkg.packageName + "." + symbol.name
+			}
+		}
+
+	case "SelectorExpr": // pkg.OldFunc, x.OldMethod, x.OldField, T.OldMethod
+		var nX = n.Children[0]
+		var selName = n.Children[1].Name
+		if importPath, isImport := tr.getImportPath(nX); isImport {
+			if pkg := tr.findScannedPackage(importPath); pkg != nil {
+				for _, symbol := range pkg.symbols.lookup(selName) {
+					if symbol.deprecation != nil && (symbol.kind == "func" || symbol.kind == "type" || symbol.kind == "const") {
+						return symbol, pkg.packageName + "." + symbol.name
+					}
+				}
+			}
+			return nil, ""
+		}
+		if t, ok := tr.exprType(nX); ok {
+			return findDeprecatedMember(tr, t, selName)
+		}
+		if nX.TypeStr == "Ident" && nX.FindLocalDeclaration(nX.Name) != nil {
+			return nil, ""
+		}
+		if t, ok := tr.typeExprType(nX); ok { // method expression, e.g. (*Config).Start
+			return findDeprecatedMember(tr, t, selName)
+		}
+	}
+	return nil, ""
+}
+
+// findDeprecatedMember returns the deprecated field or method of a type (or of a type embedded in it), or nil.
+func findDeprecatedMember(tr *typeResolver, t goType, name string) (*symbolInfo, string) {
+	if tr.depth > constMaxTypeResolverDepth {
+		return nil, ""
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	var pkg = tr.findScannedPackage(t.pkgPath)
+	if pkg == nil {
+		return nil, ""
+	}
+	for _, symbol := range pkg.symbols.lookup(t.name + "." + name) {
+		if symbol.deprecation != nil {
+			return symbol, pkg.packageName + "." + symbol.name
+		}
+	}
+	for _, embeddedType := range tr.embeddedTypes(t) {
+		if symbol, qualifiedName := findDeprecatedMember(tr, embeddedType, name); symbol != nil {
+			return symbol, qualifiedName
+		}
+	}
+	return nil, ""
+}
+
+//------------------------------------------------------------------------------
+
+// checkDeprecatedUsage reports a usage of a deprecated declaration, and returns true if this is an error.
+func checkDeprecatedUsage(n *fileparser.Node, symbol *symbolInfo, qualifiedName string, filename string, packageName string, do DeprecatedOptions) bool {
+
+	var pos = nodePosition(filename, n)
+	if isSuppressed(constCheckIDDeprecated, pos) {
+		return false
+	}
+	var usage = deprecatedUsage{File: getBaselineFilename(do.rootDir, filename), Function: getEnclosingFunctionName(n, packageName), Symbol: qualifiedName}
+	deprecatedUsages[usage]++
+
+	var d = symbol.deprecation
+	var message = fmt.Sprintf("Use of deprecated %s in %s:%d, declared in %s", qualifiedName, filename, n.Line, symbol.filename)
+	if d.use != "" {
+		message += ": use " + d.use + " instead"
+	} else if d.message != "" {
+		message += ": " + strings.TrimSpace(strings.TrimPrefix(d.message, constDeprecatedDocPrefix))
+	}
+
+	var since, err = parseDeprecatedSince(d.since)
+	if d.since == "" || err != nil {
+		if util.IsWarn() {
+			util.Warn("%s", message)
+		}
+		return false
+	}
+	message += " (deprecated since " + d.since + ")"
+	var deadline = since.AddDate(0, do.GraceMonths, 0)
+	if time.Now().Before(deadline) {
+		if util.IsWarn() {
+			util.Warn("%s, new usages will be reported from %s", message, deadline.Format("2006-01-02"))
+		}
+		return false
+	}
+	if do.UpdateBaseline {
+		if util.IsWarn() {
+			util.Warn("%s, added to the baseline", message)
+		}
+		return false
+	}
+	if do.baseline[usage] > 0 {
+		do.baseline[usage]--
+		if util.IsWarn() {
+			util.Warn("%s, known usage of the baseline", message)
+		}
+		return false
+	}
+	notPass(constCheckIDDeprecated, pos, "%s", message)
+	return true
+}
+
+// getBaselineFilename returns the path of a file relative to the scanned directory, with slashes, so that the
+// baseline does not depend on how -dir is given (e.g. "." locally and an absolute path in a CI).
+func getBaselineFilename(rootDir string, filename string) string {
+	var absRootDir, err = filepath.Abs(rootDir)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	relFilename, err := filepath.Rel(absRootDir, absFilename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	return filepath.ToSlash(relFilename)
+}
+
+// parseDeprecatedSince returns the date of "YYYY-MM" (or "YYYY-MM-DD").
+func parseDeprecatedSince(since string) (time.Time, error) {
+	if t, err := time.Parse("2006-01", since); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", since)
+}
+
+//------------------------------------------------------------------------------
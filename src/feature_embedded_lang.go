@@ -0,0 +1,250 @@
+package src
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// EmbeddedLangOptions are the functions and methods taking a string in another language (a regular
+// expression, a template, JSON, ...), which is checked at analysis time.
+type EmbeddedLangOptions struct {
+	functions map[string][]embeddedLangFunction // by function or method name
+	languages map[string]*embeddedLanguage      // by name, e.g. "regexp"
+	Timeout   time.Duration                     // for each run of an external validator, 0 for none
+}
+
+// embeddedLanguage is a language of the strings passed to some functions, with its validator.
+type embeddedLanguage struct {
+	name     string
+	validate func(text string, timeout time.Duration) []embeddedLangError
+}
+
+// embeddedLangError is an error found by a validator.
+type embeddedLangError struct {
+	offset  int // in the checked text, -1 if unknown
+	message string
+}
+
+// embeddedLangFunction is a function (e.g. "regexp.MustCompile:1") or a method by receiver type
+// (e.g. "(*text/template.Template).Parse:1") taking a string in a language.
+type embeddedLangFunction struct {
+	language *embeddedLanguage
+	pkg      string // import path or package name, e.g. "regexp"
+	typeName string // type of the receiver for a method, "" for a function
+	argument int    // starting from 1
+}
+
+const constDisclaimerEmbeddedLang = "## To ignore this error (e.g. if you think this is a false positive), put " + constDirectivePrefix +
+	constIgnoreDirective + " " + constCheckIDEmbeddedLang + " on top of the statement."
+
+//------------------------------------------------------------------------------
+
+// NewEmbeddedLangOptions returns options knowing the built-in languages, without any function.
+func NewEmbeddedLangOptions() EmbeddedLangOptions {
+	var elo = EmbeddedLangOptions{functions: make(map[string][]embeddedLangFunction), languages: make(map[string]*embeddedLanguage)}
+	for name, validate := range builtinEmbeddedLanguages {
+		elo.languages[name] = &embeddedLanguage{name: name, validate: validate}
+	}
+	return elo
+}
+
+// AddBuiltinFunctions adds the functions of the standard library taking a string in a built-in language,
+// e.g. regexp.MustCompile() or time.Parse().
+func (elo *EmbeddedLangOptions) AddBuiltinFunctions() {
+	for _, str := range builtinEmbeddedLangFunctions {
+		if err := elo.AddFunction(str); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// AddBinary adds a language checked by an external program, e.g. "graphql=graphql-lint --stdin":
+// the string is given to its standard input, and any output with a nonzero exit code is an error.
+func (elo *EmbeddedLangOptions) AddBinary(str string) error {
+	var index = strings.Index(str, "=")
+	if index == -1 {
+		return fmt.Errorf("missing language name in %s, expected e.g. graphql=graphql-lint --stdin", str)
+	}
+	var name, command = strings.TrimSpace(str[:index]), strings.Fields(str[index+1:])
+	if name == "" || len(command) == 0 {
+		return fmt.Errorf("invalid validator %s, expected e.g. graphql=graphql-lint --stdin", str)
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return fmt.Errorf("cannot find the program %s: %s", command[0], err.Error())
+	}
+	elo.languages[name] = &embeddedLanguage{name: name, validate: func(text string, timeout time.Duration) []embeddedLangError {
+		return runEmbeddedLangBinary(command, text, timeout)
+	}}
+	return nil
+}
+
+// AddFunction adds a function (e.g. "regexp=mypkg.MustMatch:1") or a method by receiver type
+// (e.g. "graphql=(*mypkg.Client).Query:2") taking a string in a language, built-in or added with AddBinary.
+func (elo *EmbeddedLangOptions) AddFunction(str string) error {
+	var indexLang = strings.Index(str, "=")
+	if indexLang == -1 {
+		return fmt.Errorf("missing language in %s, expected e.g. regexp=mypkg.MustMatch:1", str)
+	}
+	var language, ok = elo.languages[str[:indexLang]]
+	if !ok {
+		return fmt.Errorf("unknown language %s in %s (known languages: %s)", str[:indexLang], str, strings.Join(elo.languageNames(), ", "))
+	}
+	var name = str[indexLang+1:]
+	var index = strings.LastIndex(name, ":")
+	if index == -1 {
+		return fmt.Errorf("missing argument index in %s", str)
+	}
+	var function = embeddedLangFunction{language: language}
+	var err error
+	function.argument, err = strconv.Atoi(name[index+1:])
+	if err != nil || function.argument < 1 {
+		return fmt.Errorf("invalid argument index in %s", str)
+	}
+	name = name[:index]
+	var funcName string
+	if strings.HasPrefix(name, "(") {
+		var matches = regexpSQLQueryMethod.FindStringSubmatch(name)
+		if matches == nil {
+			return fmt.Errorf("invalid method %s, expected e.g. (*text/template.Template).Parse:1", str)
+		}
+		function.pkg, function.typeName, funcName = matches[1], matches[2], matches[3]
+	} else {
+		var indexDot = strings.LastIndex(name, ".")
+		if indexDot == -1 {
+			return fmt.Errorf("missing package in %s, expected e.g. regexp.MustCompile:1", str)
+		}
+		function.pkg, funcName = name[:indexDot], name[indexDot+1:]
+	}
+	elo.functions[funcName] = append(elo.functions[funcName], function)
+	return nil
+}
+
+func (elo *EmbeddedLangOptions) languageNames() (names []string) {
+	for name := range elo.languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func (elo *EmbeddedLangOptions) isEnabled() bool {
+	return len(elo.functions) > 0
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoEmbeddedLangVisit checks the string passed to a function of the embedded languages, if n is a call
+// to such a function and if the string can be computed.
+func ParanoEmbeddedLangVisit(n *fileparser.Node, filename string, tr *typeResolver, elo EmbeddedLangOptions) bool {
+	if n.TypeStr != "CallExpr" {
+		return false
+	}
+	var function, ok = findEmbeddedLangFunction(n, tr, elo)
+	if !ok {
+		return false
+	}
+	var args = n.CallArgs()
+	if function.argument > len(args) || n.CallHasEllipsis() {
+		return false
+	}
+	var qe = &queryEvaluator{tr: tr, placeholder: "?"}
+	var value = qe.eval(args[function.argument-1])
+	if !value.complete {
+		if util.IsInfo() {
+			util.Info("    Cannot compute the %s in '%s': %s", function.language.name, filename, args[function.argument-1].Bytes)
+		}
+		return false
+	}
+	var errs = function.language.validate(value.str, elo.Timeout)
+	if len(errs) == 0 {
+		return false
+	}
+	var pos = nodePosition(filename, n)
+	var qi = queryInfo{strQuery: value.str, filename: filename, pos: pos, sources: value.sources}
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, getQueryLocation(qi, e.offset)+": "+e.message)
+	}
+	notPass(constCheckIDEmbeddedLang, pos, "Invalid %s in %s:%d: %s\n%s\n%s", function.language.name, filename, n.Line,
+		getStrTruncated(value.str), strings.Join(messages, "\n"), constDisclaimerEmbeddedLang)
+	return true
+}
+
+// findEmbeddedLangFunction returns the function of the embedded languages called by nCall, if any: a function
+// of another package (e.g. "regexp.MustCompile(s)"), of the current package (e.g. "mustMatch(s)"), or a
+// method (e.g. "t.Parse(s)").
+func findEmbeddedLangFunction(nCall *fileparser.Node, tr *typeResolver, elo EmbeddedLangOptions) (function embeddedLangFunction, ok bool) {
+	var nFun = nCall.Children[0]
+	switch nFun.TypeStr {
+	case "Ident":
+		if nFun.FindLocalDeclaration(nFun.Name) != nil {
+			return
+		}
+		for _, function = range elo.functions[nFun.Name] {
+			if function.typeName == "" && function.pkg == tr.pkg.packageName {
+				return function, true
+			}
+		}
+	case "SelectorExpr":
+		var functions = elo.functions[nFun.Children[1].Name]
+		if len(functions) == 0 {
+			return
+		}
+		if importPath, isImport := tr.getImportPath(nFun.Children[0]); isImport {
+			for _, function = range functions {
+				if function.typeName == "" && (function.pkg == importPath || function.pkg == fileparser.DefaultPackageName(importPath)) {
+					return function, true
+				}
+			}
+			return
+		}
+		var receiverType, ok2 = tr.exprType(nFun.Children[0])
+		if !ok2 {
+			return
+		}
+		var types = append([]goType{receiverType}, tr.embeddedTypes(receiverType)...)
+		for _, t := range types {
+			for _, function = range functions {
+				if function.typeName != "" && isSameType(function.pkg, function.typeName, t) {
+					return function, true
+				}
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// runEmbeddedLangBinary runs an external validator with text as input: any output with a nonzero exit code
+// is an error, whose position is found as in the output of -sql-query-lint-binary.
+func runEmbeddedLangBinary(command []string, text string, timeout time.Duration) (errs []embeddedLangError) {
+	var out, exitCode, err = util.RunCmdWithStdin(text, timeout, command[0], command[1:])
+	if err != nil {
+		if util.IsWarn() {
+			util.Warn("Cannot check an embedded language: %s", err.Error())
+		}
+		return nil
+	}
+	if exitCode == 0 {
+		return nil
+	}
+	if strings.TrimSpace(out) == "" {
+		out = command[0] + " exited with code " + strconv.Itoa(exitCode)
+	}
+	for _, diagnostic := range parseTextOutput(out, text) {
+		errs = append(errs, embeddedLangError{offset: diagnostic.offset, message: diagnostic.message})
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -9,7 +9,7 @@ import (
 
 //------------------------------------------------------------------------------
 
-const constExaustiveFilling = "//!PARANO__EXHAUSTIVE_FILLING"
+const constExhaustiveFillingDirective = "EXHAUSTIVE_FILLING"
 
 //------------------------------------------------------------------------------
 
@@ -29,7 +29,7 @@ func ParanoExhaustiveFillingInit() *featureExhaustiveFilling {
 
 func ParanoExhaustiveFillingVisit(n *fileparser.Node, featureExhaustiveFilling *featureExhaustiveFilling) {
 
-	if n.IsCommentGroupWithComment(constExaustiveFilling) && n.Father != nil {
+	if n.IsCommentGroupWithDirective(constExhaustiveFillingDirective) && n.Father != nil {
 		var nextNode = n.NextNode()
 		if nextNode != nil && nextNode.TypeStr == "TypeSpec" {
 			if util.IsDebug() {
@@ -56,9 +56,9 @@ func ParanoExhaustiveFillingVisit(n *fileparser.Node, featureExhaustiveFilling *
 
 //------------------------------------------------------------------------------
 
-func ParanoExhaustiveFillingCheck(n *fileparser.Node, packageName string, featureExhaustiveFilling *featureExhaustiveFilling, filename1 string, filename2 string) (failedAtLeastOnce bool) {
-	if fieldsStruct, ok := featureExhaustiveFilling.exhaustiveFillingStructs[n.Name]; ok {
-		failedAtLeastOnce = commonCheckExhaustiveFilling(n, fieldsStruct, filename1, filename2)
+func ParanoExhaustiveFillingCheck(n *fileparser.Node, symbol *symbolInfo, filename1 string) (failedAtLeastOnce bool) {
+	if symbol.exhaustiveFillingFields != nil {
+		failedAtLeastOnce = commonCheckExhaustiveFilling(n, symbol.exhaustiveFillingFields, filename1, symbol.filename)
 	}
 	return
 }
@@ -67,21 +67,21 @@ func ParanoExhaustiveFillingCheck(n *fileparser.Node, packageName string, featur
 
 func ParanoExhaustiveFillingCheckGlobal(mInfosByPackageName map[string]*packageInfos) (failedAtLeastOnce bool) {
 
-	var mGlobalExhaustiveFillingStructs = make(map[string]map[string]bool)
+	var mGlobalExhaustiveFillingStructs = make(map[string]*symbolInfo)
 
 	for _, packageInfos := range mInfosByPackageName {
-		for _, fileInfos := range packageInfos.infosByFile {
-			for structName, mFields := range fileInfos.featureExhaustiveFilling.exhaustiveFillingStructs {
-				mGlobalExhaustiveFillingStructs[fileInfos.packageName+"."+structName] = mFields
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if symbol.exhaustiveFillingFields != nil {
+				mGlobalExhaustiveFillingStructs[packageInfos.packageName+"."+symbol.name] = symbol
 			}
-		}
+		})
 	}
 
 	for _, packageInfos := range mInfosByPackageName {
 		for filename1, fileInfos := range packageInfos.infosByFile {
 			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
-				if fieldsStruct, ok := mGlobalExhaustiveFillingStructs[n.Bytes]; ok {
-					failedAtLeastOnce = commonCheckExhaustiveFilling(n, fieldsStruct, filename1, "???")
+				if symbol, ok := mGlobalExhaustiveFillingStructs[n.Bytes]; ok {
+					failedAtLeastOnce = commonCheckExhaustiveFilling(n, symbol.exhaustiveFillingFields, filename1, symbol.filename)
 				}
 			})
 		}
@@ -110,8 +110,8 @@ func commonCheckExhaustiveFilling(n *fileparser.Node, fieldsStruct map[string]bo
 		}
 
 		if len(missingFields) > 0 {
-			util.NotPass("missing fields(s) %s in declaration \"%s{}\" in %s, type declared with %s in %s",
-				strings.Join(missingFields, ", "), n.Bytes, filename1, constExaustiveFilling, filename2)
+			notPass(constCheckIDExhaustiveFilling, nodePosition(filename1, n), "missing fields(s) %s in declaration \"%s{}\" in %s, type declared with %s in %s",
+				strings.Join(missingFields, ", "), n.Bytes, filename1, constDirectivePrefix+constExhaustiveFillingDirective, filename2)
 			failedAtLeastOnce = true
 		}
 	}
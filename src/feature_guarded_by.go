@@ -0,0 +1,549 @@
+package src
+
+import (
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constGuardedByDirective = "GUARDED_BY"
+const constRequiresLockDirective = "REQUIRES_LOCK"
+
+//------------------------------------------------------------------------------
+
+// lockDirective is a //!PARANO__GUARDED_BY directive on a field, or a //!PARANO__REQUIRES_LOCK directive
+// on a function or a method.
+type lockDirective struct {
+	mutexes  []string         // e.g. "mu" for a field of the struct or of the receiver, or "cacheMu" for a variable
+	nComment *fileparser.Node // CommentGroup
+}
+
+// heldLocks are the mutexes locked at some point of a function, by expression (e.g. "s.mu"):
+// "Lock" or "RLock".
+type heldLocks map[string]string
+
+//------------------------------------------------------------------------------
+
+type featureGuardedBy struct {
+	guardedByDecl    map[string]*lockDirective // by "Type.Field"
+	requiresLockDecl map[string]*lockDirective // by function name, "Type.Method" for a method
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoGuardedByInit() *featureGuardedBy {
+	return &featureGuardedBy{
+		guardedByDecl:    make(map[string]*lockDirective),
+		requiresLockDecl: make(map[string]*lockDirective),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoGuardedByVisit(n *fileparser.Node, feat *featureGuardedBy) {
+
+	if n.TypeStr != "CommentGroup" || n.Father == nil {
+		return
+	}
+	if directive := n.GetCommentGroupDirective(constGuardedByDirective); directive != nil && n.Father.TypeStr == "Field" {
+		for _, name := range getFieldDeclNames(n.Father) {
+			if util.IsDebug() {
+				util.DebugPrintf("....... GuardedBy: >= %s <=", name)
+			}
+			feat.guardedByDecl[name] = &lockDirective{mutexes: directive.Args, nComment: n}
+		}
+	}
+	if directive := n.GetCommentGroupDirective(constRequiresLockDirective); directive != nil && n.Father.TypeStr == "FuncDecl" {
+		var name = n.Father.Name
+		if n.Father.IsMethod() {
+			name = n.Father.ReceiverTypeName() + "." + name
+		}
+		if util.IsDebug() {
+			util.DebugPrintf("....... RequiresLock: >= %s <=", name)
+		}
+		feat.requiresLockDecl[name] = &lockDirective{mutexes: directive.Args, nComment: n}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoGuardedByCheckGlobal reports, in each function, the accesses to the fields declared with
+// //!PARANO__GUARDED_BY and the calls to the functions declared with //!PARANO__REQUIRES_LOCK, when the
+// mutex is not locked earlier in the function (or locked with RLock() only, for a write).
+func ParanoGuardedByCheckGlobal(mInfosByPackageName map[string]*packageInfos) (failedAtLeastOnce bool) {
+
+	for _, packageInfos := range mInfosByPackageName {
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if symbol.guardedBy != nil {
+				checkGuardedByDirective(packageInfos, symbol)
+			}
+			if symbol.requiresLock != nil && len(symbol.requiresLock.mutexes) == 0 {
+				notPass(constCheckIDDirective, nodePosition(symbol.filename, symbol.requiresLock.nComment), "Directive %s on %s in %s:%d: missing mutex",
+					constDirectivePrefix+constRequiresLockDirective, symbol.name, symbol.filename, symbol.requiresLock.nComment.Line)
+			}
+		})
+	}
+
+	for _, packageInfos := range mInfosByPackageName {
+		for filename, fileInfos := range packageInfos.infosByFile {
+			var tr = newTypeResolver(mInfosByPackageName, packageInfos, filename)
+			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+				if n.TypeStr != "FuncDecl" || len(n.Children) == 0 || n.Children[len(n.Children)-1].TypeStr != "BlockStmt" {
+					return
+				}
+				var la = &lockAnalysis{tr: tr, filename: filename}
+				la.analyzeStmt(n.Children[len(n.Children)-1], la.initialLocks(n))
+				if la.failed {
+					failedAtLeastOnce = true
+				}
+			})
+		}
+	}
+	return
+}
+
+// checkGuardedByDirective reports a //!PARANO__GUARDED_BY directive which does not give exactly one mutex,
+// or gives one which is neither a field of the struct nor a package-level variable.
+func checkGuardedByDirective(packageInfos *packageInfos, symbol *symbolInfo) {
+	var d = symbol.guardedBy
+	var pos = nodePosition(symbol.filename, d.nComment)
+	if len(d.mutexes) != 1 {
+		notPass(constCheckIDDirective, pos, "Directive %s on %s in %s:%d: expected one mutex, got %d",
+			constDirectivePrefix+constGuardedByDirective, symbol.name, symbol.filename, d.nComment.Line, len(d.mutexes))
+		return
+	}
+	var typeName = symbol.name[:strings.Index(symbol.name, ".")]
+	if packageInfos.symbols.lookupDecl(typeName, "type") == nil || isMutexField(packageInfos, symbol) ||
+		packageInfos.symbols.lookupDecl(d.mutexes[0], "var") != nil {
+		return
+	}
+	notPass(constCheckIDDirective, pos, "Directive %s on %s in %s:%d: no field or package-level variable %s in %s",
+		constDirectivePrefix+constGuardedByDirective, symbol.name, symbol.filename, d.nComment.Line, d.mutexes[0], typeName)
+}
+
+// isMutexField returns true if the mutex of a //!PARANO__GUARDED_BY directive on a field is a field of the
+// same struct (rather than a package-level variable), or if the struct is unknown.
+func isMutexField(packageInfos *packageInfos, symbol *symbolInfo) bool {
+	var typeSymbol = packageInfos.symbols.lookupDecl(symbol.name[:strings.Index(symbol.name, ".")], "type")
+	if typeSymbol == nil {
+		return true
+	}
+	for _, nField := range typeSymbol.declNode.StructFields() {
+		if hasFieldName(nField, symbol.guardedBy.mutexes[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFieldName returns true if a Field of a struct declares this name, e.g. "mu" for "mu sync.Mutex",
+// or "Mutex" for the embedded "sync.Mutex".
+func hasFieldName(nField *fileparser.Node, name string) bool {
+	var names = nField.DeclaredNames()
+	if len(names) == 0 && nField.FieldType() != nil {
+		var typeName = strings.TrimPrefix(nField.FieldType().Bytes, "*")
+		names = []string{typeName[strings.LastIndex(typeName, ".")+1:]}
+	}
+	for _, fieldName := range names {
+		if fieldName == name {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// lockAnalysis follows the mutexes locked and unlocked along the statements of a function, in order:
+// the branches of an if, a switch or a select are analyzed separately and then merged (a mutex remains
+// locked after them if it is locked at the end, or at the "break", of each branch which does not return),
+// and the body of a loop is analyzed once. A function literal is analyzed with the mutexes locked where it
+// is written, except for a goroutine.
+type lockAnalysis struct {
+	tr           *typeResolver
+	filename     string
+	failed       bool
+	switchBreaks *[]heldLocks // mutexes locked at the "break" of the innermost switch or select, nil in a loop
+}
+
+// initialLocks returns the mutexes declared with //!PARANO__REQUIRES_LOCK on a FuncDecl.
+func (la *lockAnalysis) initialLocks(nFuncDecl *fileparser.Node) heldLocks {
+	var held = make(heldLocks)
+	var symbol *symbolInfo
+	if nFuncDecl.IsMethod() {
+		symbol = la.tr.pkg.symbols.lookupDecl(nFuncDecl.ReceiverTypeName()+"."+nFuncDecl.Name, "method")
+	} else {
+		symbol = la.tr.pkg.symbols.lookupDecl(nFuncDecl.Name, "func")
+	}
+	if symbol == nil || symbol.requiresLock == nil {
+		return held
+	}
+	var receiverName = nFuncDecl.ReceiverName()
+	var receiverType = goType{pkgPath: la.tr.pkg.packageName, pkgName: la.tr.pkg.packageName, name: nFuncDecl.ReceiverTypeName()}
+	for _, mutex := range symbol.requiresLock.mutexes {
+		if receiverName != "" && !strings.Contains(mutex, ".") {
+			for _, key := range la.mutexKeys(receiverName, receiverType, mutex) {
+				held[key] = "Lock"
+			}
+		} else {
+			held[mutex] = "Lock"
+		}
+	}
+	return held
+}
+
+// analyzeStmt analyzes a statement with the mutexes locked before it, and returns the mutexes locked
+// after it, and true if it does not continue to the next statement (return, break, panic() ...).
+func (la *lockAnalysis) analyzeStmt(n *fileparser.Node, held heldLocks) (heldLocks, bool) {
+
+	switch n.TypeStr {
+
+	case "BlockStmt", "CaseClause", "CommClause", "LabeledStmt":
+		return la.analyzeSequence(n.Children, held)
+
+	case "IfStmt": // if init; cond {...} else ...
+		var bodyIndex = indexOfChildType(n, "BlockStmt")
+		held, _ = la.analyzeSequence(n.Children[:bodyIndex], held)
+		var branches = []*fileparser.Node{n.Children[bodyIndex]}
+		if bodyIndex+1 < len(n.Children) {
+			branches = append(branches, n.Children[bodyIndex+1])
+		}
+		return la.analyzeBranches(branches, held, bodyIndex+1 == len(n.Children))
+
+	case "ForStmt", "RangeStmt":
+		var bodyIndex = len(n.Children) - 1
+		held, _ = la.analyzeSequence(n.Children[:bodyIndex], held)
+		var outerBreaks = la.switchBreaks
+		la.switchBreaks = nil // a "break" exits the loop
+		var heldAfterBody, terminates = la.analyzeStmt(n.Children[bodyIndex], held.copy())
+		la.switchBreaks = outerBreaks
+		if terminates {
+			return held, false
+		}
+		return held.intersect(heldAfterBody), false
+
+	case "SwitchStmt", "TypeSwitchStmt", "SelectStmt":
+		var bodyIndex = len(n.Children) - 1
+		held, _ = la.analyzeSequence(n.Children[:bodyIndex], held)
+		var hasDefault = false
+		for _, nClause := range n.Children[bodyIndex].Children {
+			if strings.HasPrefix(nClause.Bytes, "default") {
+				hasDefault = true
+			}
+		}
+		var outerBreaks, breaks = la.switchBreaks, []heldLocks{}
+		la.switchBreaks = &breaks
+		var heldAfter, terminates = la.analyzeBranches(n.Children[bodyIndex].Children, held, !hasDefault)
+		la.switchBreaks = outerBreaks
+		for _, heldAtBreak := range breaks { // a "break" continues after the switch
+			if terminates {
+				heldAfter, terminates = heldAtBreak, false
+			} else {
+				heldAfter = heldAfter.intersect(heldAtBreak)
+			}
+		}
+		return heldAfter, terminates
+
+	case "ReturnStmt":
+		la.checkExpr(n, held)
+		return held, true
+
+	case "BranchStmt": // break, continue, goto, fallthrough
+		if n.Bytes == "break" && la.switchBreaks != nil {
+			*la.switchBreaks = append(*la.switchBreaks, held)
+		}
+		return held, true
+
+	case "ExprStmt":
+		var nCall = n.Children[0]
+		if method, key := getLockCall(nCall); method != "" {
+			switch method {
+			case "Lock", "RLock":
+				held = held.copy()
+				held[key] = method
+			case "Unlock", "RUnlock":
+				held = held.copy()
+				delete(held, key)
+			}
+			return held, false
+		}
+		la.checkExpr(n, held)
+		return held, nCall.TypeStr == "CallExpr" && nCall.Children[0].Bytes == "panic"
+
+	case "GoStmt": // the function runs in another goroutine, without the mutexes
+		var nCall = n.Children[0]
+		for _, nArg := range nCall.CallArgs() {
+			la.checkExpr(nArg, held)
+		}
+		la.checkCall(nCall, make(heldLocks))
+		la.checkExpr(nCall.Children[0], make(heldLocks))
+		return held, false
+
+	case "DeferStmt": // "defer mu.Unlock()" keeps the mutex locked until the function returns
+		if method, _ := getLockCall(n.Children[0]); method == "" {
+			la.checkExpr(n, held)
+		}
+		return held, false
+	}
+
+	la.checkExpr(n, held) // assignment, declaration ...
+	return held, false
+}
+
+// analyzeSequence analyzes statements and expressions in order (e.g. the init statement and the condition of
+// an if), see analyzeStmt.
+func (la *lockAnalysis) analyzeSequence(nodes []*fileparser.Node, held heldLocks) (heldLocks, bool) {
+	for _, n := range nodes {
+		if !isStatementNode(n) {
+			la.checkExpr(n, held)
+			continue
+		}
+		var terminates bool
+		if held, terminates = la.analyzeStmt(n, held); terminates {
+			return held, true
+		}
+	}
+	return held, false
+}
+
+// analyzeBranches analyzes the branches of an if, a switch or a select, and returns the mutexes locked at
+// the end of all the branches which do not terminate (and before them if the branches may all be skipped).
+func (la *lockAnalysis) analyzeBranches(branches []*fileparser.Node, held heldLocks, mayBeSkipped bool) (heldLocks, bool) {
+	var merged heldLocks
+	if mayBeSkipped {
+		merged = held
+	}
+	for _, nBranch := range branches {
+		var heldAfterBranch, terminates = la.analyzeStmt(nBranch, held.copy())
+		if terminates {
+			continue
+		}
+		if merged == nil {
+			merged = heldAfterBranch
+		} else {
+			merged = merged.intersect(heldAfterBranch)
+		}
+	}
+	if merged == nil {
+		return held, true
+	}
+	return merged, false
+}
+
+//------------------------------------------------------------------------------
+
+// checkExpr reports the accesses to the guarded fields, and the calls to the functions requiring a lock,
+// in a node which is not a statement of the analysis (an expression, an assignment ...).
+func (la *lockAnalysis) checkExpr(n *fileparser.Node, held heldLocks) {
+	switch n.TypeStr {
+	case "FuncLit":
+		la.analyzeStmt(n.Children[len(n.Children)-1], held.copy())
+		return
+	case "SelectorExpr":
+		la.checkFieldAccess(n, held)
+	case "CallExpr":
+		la.checkCall(n, held)
+	}
+	for _, child := range n.Children {
+		la.checkExpr(child, held)
+	}
+}
+
+// checkFieldAccess reports the access to a guarded field, e.g. "s.items", without its mutex locked.
+func (la *lockAnalysis) checkFieldAccess(n *fileparser.Node, held heldLocks) {
+	var nX = n.Children[0]
+	var fieldName = n.Children[1].Name
+	if isLocalValueUnshared(nX) {
+		return
+	}
+	var t, ok = la.tr.exprType(nX)
+	if !ok {
+		return
+	}
+	var symbol, pkg = la.findGuardedField(t, fieldName)
+	if symbol == nil || len(symbol.guardedBy.mutexes) != 1 {
+		return
+	}
+	var mutex = symbol.guardedBy.mutexes[0]
+	var keys []string
+	if isMutexField(pkg, symbol) {
+		keys = la.mutexKeys(getLockKey(nX), t, mutex)
+	} else if pkg == la.tr.pkg { // package-level variable, e.g. "cacheMu.Lock()"
+		keys = []string{mutex}
+	} else { // e.g. "cache.Mu.Lock()"
+		keys = []string{pkg.packageName + "." + mutex}
+	}
+	var isWrite = getImmutableWriteKind(n) != ""
+	var method = held.find(keys)
+	if method == "Lock" || (method == "RLock" && !isWrite) {
+		return
+	}
+	var nX2 = keys[0]
+	if method == "RLock" {
+		notPass(constCheckIDGuardedBy, nodePosition(la.filename, n), "Write to %s in %s:%d with only %s.RLock(), declared with %s %s in %s",
+			n.Bytes, la.filename, n.Line, nX2, constDirectivePrefix+constGuardedByDirective, mutex, symbol.filename)
+	} else {
+		notPass(constCheckIDGuardedBy, nodePosition(la.filename, n), "Access to %s in %s:%d without %s.Lock(), declared with %s %s in %s",
+			n.Bytes, la.filename, n.Line, nX2, constDirectivePrefix+constGuardedByDirective, mutex, symbol.filename)
+	}
+	la.failed = true
+}
+
+// checkCall reports the call to a function or a method declared with //!PARANO__REQUIRES_LOCK without
+// its mutexes locked.
+func (la *lockAnalysis) checkCall(nCall *fileparser.Node, held heldLocks) {
+	var nFun = nCall.Children[0]
+	var symbol = la.tr.funcSymbol(nFun)
+	if symbol == nil || symbol.requiresLock == nil {
+		return
+	}
+	for _, mutex := range symbol.requiresLock.mutexes {
+		var keys []string
+		if symbol.kind == "method" && nFun.TypeStr == "SelectorExpr" && !strings.Contains(mutex, ".") {
+			var nX = nFun.Children[0]
+			if t, ok := la.tr.exprType(nX); ok {
+				keys = la.mutexKeys(getLockKey(nX), t, mutex)
+			}
+		} else if symbol.kind == "func" && !strings.Contains(mutex, ".") {
+			keys = []string{mutex}
+		}
+		if len(keys) == 0 || held.find(keys) != "" {
+			continue
+		}
+		notPass(constCheckIDGuardedBy, nodePosition(la.filename, nCall), "Call to %s in %s:%d without %s.Lock(), declared with %s %s in %s",
+			nCall.Name, la.filename, nCall.Line, keys[0], constDirectivePrefix+constRequiresLockDirective, mutex, symbol.filename)
+		la.failed = true
+	}
+}
+
+// findGuardedField returns the field declared with //!PARANO__GUARDED_BY of a type (or of a type embedded
+// in it) and its package, or nil.
+func (la *lockAnalysis) findGuardedField(t goType, name string) (*symbolInfo, *packageInfos) {
+	var tr = la.tr
+	if tr.depth > constMaxTypeResolverDepth {
+		return nil, nil
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	var pkg = tr.findScannedPackage(t.pkgPath)
+	if pkg == nil {
+		return nil, nil
+	}
+	for _, symbol := range pkg.symbols.lookup(t.name + "." + name) {
+		if symbol.guardedBy != nil {
+			return symbol, pkg
+		}
+	}
+	for _, embeddedType := range tr.embeddedTypes(t) {
+		if symbol, embeddedPkg := la.findGuardedField(embeddedType, name); symbol != nil {
+			return symbol, embeddedPkg
+		}
+	}
+	return nil, nil
+}
+
+// mutexKeys returns the expressions which lock the mutex field of x, e.g. "s.mu" for "s.mu.Lock()", or
+// "s.Mutex" and "s" for "s.Lock()" if the mutex is embedded.
+func (la *lockAnalysis) mutexKeys(xKey string, t goType, mutex string) []string {
+	var keys = []string{xKey + "." + mutex}
+	for _, embeddedType := range la.tr.embeddedTypes(t) {
+		if embeddedType.name == mutex {
+			keys = append(keys, xKey)
+		}
+	}
+	return keys
+}
+
+//------------------------------------------------------------------------------
+
+// getLockCall returns the method and the expression of the mutex of a call such as "s.mu.Lock()", or "".
+func getLockCall(nCall *fileparser.Node) (method string, key string) {
+	if nCall.TypeStr != "CallExpr" || len(nCall.Children) != 1 || nCall.Children[0].TypeStr != "SelectorExpr" {
+		return
+	}
+	var nFun = nCall.Children[0]
+	switch nFun.Children[1].Name {
+	case "Lock", "RLock", "Unlock", "RUnlock":
+		return nFun.Children[1].Name, getLockKey(nFun.Children[0])
+	}
+	return
+}
+
+// getLockKey returns the expression of a mutex (or of a value containing it) without the spaces and the
+// parentheses, e.g. "s.mu" for "(s).mu".
+func getLockKey(n *fileparser.Node) string {
+	return strings.NewReplacer(" ", "", "\t", "", "(", "", ")", "").Replace(n.Bytes)
+}
+
+// isLocalValueUnshared returns true if n is a local variable declared with a composite literal in the
+// function, e.g. "s" after "s := &Store{}", which cannot be used by another goroutine yet.
+func isLocalValueUnshared(n *fileparser.Node) bool {
+	if n.TypeStr != "Ident" {
+		return false
+	}
+	var declNode = n.FindLocalDeclaration(n.Name)
+	if declNode == nil {
+		return false
+	}
+	var nValue = getDeclaredValue(declNode, n.Name)
+	if nValue != nil && nValue.TypeStr == "UnaryExpr" && nValue.Operator() == "&" && len(nValue.Children) == 1 {
+		nValue = nValue.Children[0]
+	}
+	return nValue != nil && nValue.TypeStr == "CompositeLit"
+}
+
+// isStatementNode returns true if n is a statement (rather than an expression) for the analysis.
+func isStatementNode(n *fileparser.Node) bool {
+	return strings.HasSuffix(n.TypeStr, "Stmt") || n.TypeStr == "CaseClause" || n.TypeStr == "CommClause"
+}
+
+// indexOfChildType returns the index of the first child of this type, or len(n.Children) if there is none.
+func indexOfChildType(n *fileparser.Node, typeStr string) int {
+	for i, child := range n.Children {
+		if child.TypeStr == typeStr {
+			return i
+		}
+	}
+	return len(n.Children)
+}
+
+//------------------------------------------------------------------------------
+
+func (held heldLocks) copy() heldLocks {
+	var result = make(heldLocks, len(held))
+	for key, method := range held {
+		result[key] = method
+	}
+	return result
+}
+
+// intersect returns the mutexes locked in both, with RLock if one of them is locked with RLock only.
+func (held heldLocks) intersect(other heldLocks) heldLocks {
+	var result = make(heldLocks)
+	for key, method := range held {
+		if otherMethod, ok := other[key]; ok {
+			if otherMethod == "RLock" {
+				method = otherMethod
+			}
+			result[key] = method
+		}
+	}
+	return result
+}
+
+// find returns how the first of these mutexes which is locked is locked ("Lock" or "RLock"), or "".
+func (held heldLocks) find(keys []string) string {
+	var result = ""
+	for _, key := range keys {
+		if method := held[key]; method == "Lock" || (method == "RLock" && result == "") {
+			result = method
+		}
+	}
+	return result
+}
+
+//------------------------------------------------------------------------------
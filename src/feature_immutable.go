@@ -0,0 +1,167 @@
+package src
+
+import (
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constImmutableDirective = "IMMUTABLE"
+
+//------------------------------------------------------------------------------
+
+type featureImmutable struct {
+	immutableDecl map[string]*fileparser.Node // ValueSpec by variable name
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoImmutableInit() *featureImmutable {
+	return &featureImmutable{
+		immutableDecl: make(map[string]*fileparser.Node),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoImmutableVisit(n *fileparser.Node, feat *featureImmutable) {
+
+	if n.IsCommentGroupWithDirective(constImmutableDirective) && n.Father != nil {
+		var valueSpecs []*fileparser.Node
+		if n.Father.TypeStr == "GenDecl" && n.Father.DepthLevel == 2 { // "var" at package level
+			for _, n2 := range n.Father.Children {
+				if n2.TypeStr == "ValueSpec" {
+					valueSpecs = append(valueSpecs, n2)
+				}
+			}
+		} else if n.Father.TypeStr == "ValueSpec" && n.Father.Father.TypeStr == "GenDecl" && n.Father.Father.DepthLevel == 2 { // within "var (...)"
+			valueSpecs = append(valueSpecs, n.Father)
+		}
+		for _, valueSpec := range valueSpecs {
+			for _, name := range valueSpec.DeclaredNames() {
+				if util.IsDebug() {
+					util.DebugPrintf("....... Immutable: >= %s <=", name)
+				}
+				feat.immutableDecl[name] = valueSpec
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoImmutableCheck(n *fileparser.Node, symbol *symbolInfo, filename string, allowedFuncs util.WildcardMap) {
+
+	if !symbol.immutable || n.TypeStr != "Ident" {
+		return
+	}
+	if n.Father != nil && n.Father.TypeStr == "SelectorExpr" && n.Index == 1 {
+		return // this is the field or the method of something else
+	}
+	if n.FindLocalDeclaration(n.Name) != nil {
+		return // this is a local variable with the same name
+	}
+	checkImmutableWrite(n, n.Name, symbol.filename, filename, allowedFuncs)
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoImmutableCheckGlobal(mInfosByPackageName map[string]*packageInfos, allowedFuncs util.WildcardMap) {
+
+	var mGlobalImmutableVars = make(map[string]*symbolInfo)
+
+	for _, packageInfos := range mInfosByPackageName {
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if symbol.immutable {
+				mGlobalImmutableVars[packageInfos.packageName+"."+symbol.name] = symbol
+			}
+		})
+	}
+
+	for _, packageInfos := range mInfosByPackageName {
+		for filename, fileInfos := range packageInfos.infosByFile {
+			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+				if n.TypeStr == "SelectorExpr" {
+					if symbol, ok := mGlobalImmutableVars[n.Bytes]; ok {
+						checkImmutableWrite(n, n.Bytes, symbol.filename, filename, allowedFuncs)
+					}
+				}
+			})
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func checkImmutableWrite(n *fileparser.Node, name string, declFilename string, filename string, allowedFuncs util.WildcardMap) {
+
+	var what = getImmutableWriteKind(n)
+	if what == "" {
+		return
+	}
+
+	var nFunc = n.Father
+	for nFunc != nil && nFunc.TypeStr != "FuncDecl" {
+		nFunc = nFunc.Father
+	}
+	if nFunc != nil {
+		if nFunc.Name == "init" && !nFunc.IsMethod() {
+			return
+		}
+		if _, ok := allowedFuncs.Find(nFunc.Name); ok {
+			if util.IsDebug() {
+				util.DebugPrintf("Allowing immutable %s to be modified in %s", name, nFunc.Name)
+			}
+			return
+		}
+	}
+
+	notPass(constCheckIDImmutable, nodePosition(filename, n), "Cannot %s %s in %s:%d, declared as immutable in %s",
+		what, name, filename, n.Line, declFilename)
+}
+
+//------------------------------------------------------------------------------
+
+// getImmutableWriteKind returns what the code does to the variable n if it may modify it
+// ("assign", "increment", "append to" ...), or "" otherwise.
+func getImmutableWriteKind(n *fileparser.Node) string {
+
+	// x.foo, x[foo], (x), *x ...
+	var nExpr = n
+	for nExpr.Father != nil &&
+		((nExpr.Index == 0 && (nExpr.Father.TypeStr == "SelectorExpr" || nExpr.Father.TypeStr == "IndexExpr")) ||
+			nExpr.Father.TypeStr == "ParenExpr" || nExpr.Father.TypeStr == "StarExpr") {
+		nExpr = nExpr.Father
+	}
+	if nExpr.Father == nil {
+		return ""
+	}
+
+	switch nExpr.Father.TypeStr {
+	case "AssignStmt":
+		for _, nLhs := range nExpr.Father.AssignStmtLhs() {
+			if nLhs == nExpr {
+				if nExpr != n {
+					return "modify"
+				}
+				return "assign"
+			}
+		}
+	case "IncDecStmt":
+		return "increment or decrement"
+	case "UnaryExpr":
+		if nExpr.Father.Operator() == "&" {
+			return "take the address of"
+		}
+	case "CallExpr":
+		if nExpr.Index == 1 && nExpr.Father.Name == "append" {
+			return "append to"
+		} else if nExpr.Index == 1 && nExpr.Father.Name == "delete" {
+			return "delete from"
+		}
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,169 @@
+package src
+
+import (
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constMustCheckErrorDirective = "MUST_CHECK_ERROR"
+
+//------------------------------------------------------------------------------
+
+type featureMustCheckError struct {
+	mustCheckErrorDecl map[string]*fileparser.Node // FuncDecl by name, "Type.Method" for a method
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoMustCheckErrorInit() *featureMustCheckError {
+	return &featureMustCheckError{
+		mustCheckErrorDecl: make(map[string]*fileparser.Node),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoMustCheckErrorVisit(n *fileparser.Node, feat *featureMustCheckError) {
+
+	if n.IsCommentGroupWithDirective(constMustCheckErrorDirective) && n.Father != nil && n.Father.TypeStr == "FuncDecl" {
+		var name = n.Father.Name
+		if n.Father.IsMethod() {
+			name = n.Father.ReceiverTypeName() + "." + name
+		}
+		if util.IsDebug() {
+			util.DebugPrintf("....... MustCheckError: >= %s <=", name)
+		}
+		feat.mustCheckErrorDecl[name] = n.Father
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoMustCheckErrorCheckGlobal reports the calls, in all the scanned packages, to the functions and methods
+// declared with //!PARANO__MUST_CHECK_ERROR whose error result is discarded.
+func ParanoMustCheckErrorCheckGlobal(mInfosByPackageName map[string]*packageInfos) (failedAtLeastOnce bool) {
+
+	for _, packageInfos := range mInfosByPackageName {
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if symbol.mustCheckError && getErrorResultIndex(symbol.declNode) == -1 {
+				notPass(constCheckIDDirective, nodePosition(symbol.filename, symbol.declNode), "Directive %s on %s in %s:%d, which does not return an error",
+					constDirectivePrefix+constMustCheckErrorDirective, symbol.name, symbol.filename, symbol.declNode.Line)
+			}
+		})
+	}
+
+	for _, packageInfos := range mInfosByPackageName {
+		for filename, fileInfos := range packageInfos.infosByFile {
+			var tr = newTypeResolver(mInfosByPackageName, packageInfos, filename)
+			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+				if n.TypeStr != "CallExpr" {
+					return
+				}
+				var symbol = tr.funcSymbol(n.Children[0])
+				if symbol == nil || !symbol.mustCheckError {
+					return
+				}
+				var errorIndex = getErrorResultIndex(symbol.declNode)
+				if errorIndex == -1 {
+					return
+				}
+				if how := getDiscardedResultKind(n, errorIndex, len(symbol.declNode.FuncResultTypes())); how != "" {
+					notPass(constCheckIDMustCheckError, nodePosition(filename, n), "Error returned by %s is %s in %s:%d, declared with %s in %s",
+						n.Children[0].Bytes, how, filename, n.Line, constDirectivePrefix+constMustCheckErrorDirective, symbol.filename)
+					failedAtLeastOnce = true
+				}
+			})
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// getErrorResultIndex returns the index of the last result of type error of a FuncDecl, or -1 if there is none.
+func getErrorResultIndex(nFuncDecl *fileparser.Node) int {
+	var types = nFuncDecl.FuncResultTypes()
+	for i := len(types) - 1; i >= 0; i-- {
+		if types[i].TypeStr == "Ident" && types[i].Name == "error" {
+			return i
+		}
+	}
+	return -1
+}
+
+// getDiscardedResultKind returns how the result at resultIndex (among resultCount results) of a function call
+// is discarded ("ignored", "ignored by defer", "assigned to _" ...), or "" if it may be used.
+func getDiscardedResultKind(nCall *fileparser.Node, resultIndex int, resultCount int) string {
+
+	if how := getDiscardingStatementKind(nCall); how != "" {
+		return how
+	}
+	var nExpr = getOutermostParenExpr(nCall)
+	if nExpr.Father == nil {
+		return ""
+	}
+
+	switch nExpr.Father.TypeStr {
+	case "AssignStmt":
+		if isDiscardedValue(nExpr, nExpr.Father.AssignStmtLhs(), nExpr.Father.AssignStmtRhs(), resultIndex, resultCount) {
+			return "assigned to _"
+		}
+	case "ValueSpec":
+		var nNames []*fileparser.Node
+		for _, child := range nExpr.Father.Children {
+			if child.TypeStr == "Ident" && len(nNames) < len(nExpr.Father.DeclaredNames()) {
+				nNames = append(nNames, child)
+			}
+		}
+		if isDiscardedValue(nExpr, nNames, nExpr.Father.ValueSpecValues(), resultIndex, resultCount) {
+			return "assigned to _"
+		}
+	}
+	return ""
+}
+
+// getDiscardingStatementKind returns how all the results of a function call are discarded by the statement
+// made of this call ("ignored", "ignored by defer" or "ignored by go"), or "" if this is not a statement.
+func getDiscardingStatementKind(nCall *fileparser.Node) string {
+	var nExpr = getOutermostParenExpr(nCall)
+	if nExpr.Father == nil {
+		return ""
+	}
+	switch nExpr.Father.TypeStr {
+	case "ExprStmt":
+		return "ignored"
+	case "DeferStmt":
+		return "ignored by defer"
+	case "GoStmt":
+		return "ignored by go"
+	}
+	return ""
+}
+
+// getOutermostParenExpr returns the outermost parentheses around n, e.g. "((f()))" for "f()", or n if there is none.
+func getOutermostParenExpr(n *fileparser.Node) *fileparser.Node {
+	for n.Father != nil && n.Father.TypeStr == "ParenExpr" {
+		n = n.Father
+	}
+	return n
+}
+
+// isDiscardedValue returns true if the result at resultIndex of the value nValue, in an assignment or
+// a declaration, is assigned to "_", e.g. "_ = f()", "a, _ := f()" or "var _, b = f(), g()".
+func isDiscardedValue(nValue *fileparser.Node, lhs []*fileparser.Node, rhs []*fileparser.Node, resultIndex int, resultCount int) bool {
+	var nTarget *fileparser.Node
+	if len(rhs) == 1 && len(lhs) == resultCount {
+		nTarget = lhs[resultIndex]
+	} else if len(lhs) == len(rhs) && resultCount == 1 {
+		for i, nRhs := range rhs {
+			if nRhs == nValue {
+				nTarget = lhs[i]
+			}
+		}
+	}
+	return nTarget != nil && nTarget.TypeStr == "Ident" && nTarget.Name == "_"
+}
+
+//------------------------------------------------------------------------------
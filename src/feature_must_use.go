@@ -0,0 +1,126 @@
+package src
+
+import (
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constMustUseDirective = "MUST_USE"
+
+//------------------------------------------------------------------------------
+
+type featureMustUse struct {
+	mustUseDecl map[string]*fileparser.Node // FuncDecl or TypeSpec by name, "Type.Method" for a method
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoMustUseInit() *featureMustUse {
+	return &featureMustUse{
+		mustUseDecl: make(map[string]*fileparser.Node),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func ParanoMustUseVisit(n *fileparser.Node, feat *featureMustUse) {
+
+	if !n.IsCommentGroupWithDirective(constMustUseDirective) || n.Father == nil {
+		return
+	}
+	var nDecl *fileparser.Node
+	var name string
+	if n.Father.TypeStr == "FuncDecl" {
+		nDecl, name = n.Father, n.Father.Name
+		if n.Father.IsMethod() {
+			name = n.Father.ReceiverTypeName() + "." + name
+		}
+	} else if n.Father.TypeStr == "TypeSpec" {
+		nDecl, name = n.Father, n.Father.Name
+	} else if nextNode := n.NextNode(); nextNode != nil && nextNode.TypeStr == "TypeSpec" {
+		nDecl, name = nextNode, nextNode.Name
+	}
+	if nDecl != nil {
+		if util.IsDebug() {
+			util.DebugPrintf("....... MustUse: >= %s <=", name)
+		}
+		feat.mustUseDecl[name] = nDecl
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoMustUseCheckGlobal reports the calls, in all the scanned packages, whose results are discarded as
+// a statement, to the functions and methods declared with //!PARANO__MUST_USE, or returning a type declared
+// with it.
+func ParanoMustUseCheckGlobal(mInfosByPackageName map[string]*packageInfos) (failedAtLeastOnce bool) {
+
+	// directive (on the function or on a type of its results) by function and method
+	var mMustUseFuncs = make(map[*symbolInfo]*symbolInfo)
+
+	for _, packageInfos := range mInfosByPackageName {
+		packageInfos.symbols.visit(func(symbol *symbolInfo) {
+			if symbol.kind != "func" && symbol.kind != "method" {
+				return
+			}
+			var resultTypes = symbol.declNode.FuncResultTypes()
+			if symbol.mustUse {
+				if len(resultTypes) == 0 {
+					notPass(constCheckIDDirective, nodePosition(symbol.filename, symbol.declNode), "Directive %s on %s in %s:%d, which does not return anything",
+						constDirectivePrefix+constMustUseDirective, symbol.name, symbol.filename, symbol.declNode.Line)
+					return
+				}
+				mMustUseFuncs[symbol] = symbol
+				return
+			}
+			var tr = newTypeResolver(mInfosByPackageName, packageInfos, symbol.filename)
+			for _, nType := range resultTypes {
+				if t, ok := tr.typeExprType(nType); ok {
+					if pkg := tr.findScannedPackage(t.pkgPath); pkg != nil {
+						if typeSymbol := pkg.symbols.lookupDecl(t.name, "type"); typeSymbol != nil && typeSymbol.mustUse {
+							mMustUseFuncs[symbol] = typeSymbol
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+
+	for _, packageInfos := range mInfosByPackageName {
+		for filename, fileInfos := range packageInfos.infosByFile {
+			var tr = newTypeResolver(mInfosByPackageName, packageInfos, filename)
+			fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+				if n.TypeStr != "CallExpr" {
+					return
+				}
+				var how = getDiscardingStatementKind(n)
+				if how == "" {
+					return
+				}
+				var symbol = tr.funcSymbol(n.Children[0])
+				var directiveSymbol, ok = mMustUseFuncs[symbol]
+				if symbol == nil || !ok {
+					return
+				}
+				var name = n.Children[0].Bytes
+				if nFun := n.Children[0]; nFun.TypeStr == "Ident" && nFun.FindLocalDeclaration(nFun.Name) != nil {
+					name += " (" + symbol.name + ")" // method value, e.g. "f (Options.WithTimeout)"
+				}
+				if directiveSymbol == symbol {
+					notPass(constCheckIDMustUse, nodePosition(filename, n), "Result of %s is %s in %s:%d, declared with %s in %s",
+						name, how, filename, n.Line, constDirectivePrefix+constMustUseDirective, symbol.filename)
+				} else {
+					notPass(constCheckIDMustUse, nodePosition(filename, n), "Result of %s is %s in %s:%d, type %s declared with %s in %s",
+						name, how, filename, n.Line, directiveSymbol.name, constDirectivePrefix+constMustUseDirective, directiveSymbol.filename)
+				}
+				failedAtLeastOnce = true
+			})
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -9,7 +9,7 @@ import (
 
 //------------------------------------------------------------------------------
 
-const constPrivateToFileComment = "//!PARANO__PRIVATE_TO_FILE"
+const constPrivateToFileDirective = "PRIVATE_TO_FILE"
 const constLocalPrivateStuffLineRegexp1 = "\n//\\s+LOCAL PRIVATE STUFF\\s*\n"
 const constLocalPrivateStuffLineRegexp2 = "\n//\\s+PRIVATE LOCAL STUFF\\s*\n"
 const constLocalPrivateStuffLineRegexp3 = "\n//\\s+LOCAL PRIVATE STUFF \\(= the content below is not expected to be used outside this file\\)\\s*\n"
@@ -18,7 +18,7 @@ const constLocalPrivateStuffLineRegexp3 = "\n//\\s+LOCAL PRIVATE STUFF \\(= the
 
 type featurePrivateToFile struct {
 	locationLocalPrivateStuff int
-	privateToFileDecl         map[string]bool
+	privateToFileDecl         map[string]*fileparser.Node // declaration node by name
 }
 
 //------------------------------------------------------------------------------
@@ -45,7 +45,7 @@ func ParanoPrivateToFileInit(fileBytes []byte) *featurePrivateToFile {
 	}
 	return &featurePrivateToFile{
 		locationLocalPrivateStuff: locationLocalPrivateStuff,
-		privateToFileDecl:         make(map[string]bool),
+		privateToFileDecl:         make(map[string]*fileparser.Node),
 	}
 }
 
@@ -57,7 +57,7 @@ func ParanoPrivateToFileVisit(n *fileparser.Node, feat *featurePrivateToFile) {
 		checkPrivateToFile(n.Children[0], feat)
 	}
 
-	if n.IsCommentGroupWithComment(constPrivateToFileComment) && n.Father != nil {
+	if n.IsCommentGroupWithDirective(constPrivateToFileDirective) && n.Father != nil {
 		checkPrivateToFile(n, feat)
 	}
 }
@@ -74,7 +74,7 @@ func checkPrivateToFile(n *fileparser.Node, feat *featurePrivateToFile) {
 					if util.IsDebug() {
 						util.DebugPrintf("....... PrivateToFile: ValueSpec: >= %s <=", name)
 					}
-					feat.privateToFileDecl[name] = true
+					feat.privateToFileDecl[name] = n2
 					break
 				}
 			}
@@ -83,14 +83,14 @@ func checkPrivateToFile(n *fileparser.Node, feat *featurePrivateToFile) {
 		if util.IsDebug() {
 			util.DebugPrintf("....... PrivateToFile: FuncDecl: >= %s %s <=", n.Father.Name, n.Father.TypeStr)
 		}
-		feat.privateToFileDecl[n.Father.Name] = true
+		feat.privateToFileDecl[n.Father.Name] = n.Father
 	} else {
 		var nextNode = n.NextNode()
 		if nextNode != nil && nextNode.TypeStr == "TypeSpec" {
 			if util.IsDebug() {
 				util.DebugPrintf("....... PrivateToFile: TypeSpec: >= %s %s <=", nextNode.Name, nextNode.TypeStr)
 			}
-			feat.privateToFileDecl[nextNode.Name] = true
+			feat.privateToFileDecl[nextNode.Name] = nextNode
 		}
 	}
 
@@ -98,17 +98,15 @@ func checkPrivateToFile(n *fileparser.Node, feat *featurePrivateToFile) {
 
 //------------------------------------------------------------------------------
 
-func ParanoPrivateToFileCheck(n *fileparser.Node, featurePrivateToFile *featurePrivateToFile, filename1 string, filename2 string, ignorePrivateToFile util.WildcardMap) {
+func ParanoPrivateToFileCheck(n *fileparser.Node, symbol *symbolInfo, filename1 string, ignorePrivateToFile util.WildcardMap) {
 
-	if filename1 != filename2 {
-		if _, ok := featurePrivateToFile.privateToFileDecl[n.Name]; ok {
-			if _, ok2 := ignorePrivateToFile.Find(n.Name); ok2 {
-				if util.IsDebug() {
-					util.DebugPrintf("Ignoring private to file: %s when used in %s (from %s)", n.Name, filename1, filename2)
-				}
-			} else {
-				util.NotPass("Cannot use %s in %s, declared as private to file in %s", n.Name, filename1, filename2)
+	if symbol.privateToFile && filename1 != symbol.filename {
+		if _, ok := ignorePrivateToFile.Find(n.Name); ok {
+			if util.IsDebug() {
+				util.DebugPrintf("Ignoring private to file: %s when used in %s (from %s)", n.Name, filename1, symbol.filename)
 			}
+		} else {
+			notPass(constCheckIDPrivateToFile, nodePosition(filename1, n), "Cannot use %s in %s, declared as private to file in %s", n.Name, filename1, symbol.filename)
 		}
 	}
 	return
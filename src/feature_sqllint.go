@@ -2,55 +2,189 @@ package src
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/sqlparser"
 	"github.com/phrounz/go-parano/src/util"
 )
 
 //------------------------------------------------------------------------------
 
 type SQLQueryOptions struct {
-	FunctionsNames util.WildcardMap
-	AllInOne       bool
-	LintBinary     string
-	IgnoreGoFiles  util.WildcardMap
+	FunctionsNames   util.WildcardMap
+	MethodsNames     map[string][]sqlQueryMethod // by method name
+	StructFields     []sqlQueryStructField
+	AllInOne         bool
+	LintBinary       string
+	LintJobs         int                // number of linters run at the same time
+	LintTimeout      time.Duration      // for each run of the linter, 0 for none
+	LintServer       bool               // if true, the linter is run once and reads the queries line by line
+	Dialect          *sqlparser.Dialect // dialect of the queries (placeholders), nil if unknown
+	LintDialect      *sqlparser.Dialect // built-in linter, nil if not used
+	Schema           *sqlparser.Schema  // tables and columns checked by the built-in linter, nil if not used
+	IgnoreGoFiles    util.WildcardMap
+	IgnoredRules     util.WildcardMap // rules of the linter whose errors are ignored, e.g. "L010" or "L01*"
+	lintAdapter      sqlLintAdapter
+	lintCache        *sqlLintCache // nil if not used
+	InjectionCheck   bool
+	sanitizers       *sqlSanitizers // for InjectionCheck
+	ExportFile       string         // inventory of the queries written by WriteSQLQueryExport, "" if none
+	rules            []*sqlPolicyRule
+	migrationDirs    util.WildcardMap // for the rule drop-outside-migrations
+	httpHandlerTypes []sqlQueryMethod // for the rule missing-limit-in-handler (the argument index is not used)
+}
+
+// sqlQueryArgument is the position of the query in the arguments of a function or a method.
+type sqlQueryArgument struct {
+	index        int  // starting from 1
+	variadicArgs bool // true if the query is followed by the values of its placeholders, e.g. "Query:1..."
+}
+
+// sqlQueryMethod is a method taking a query, e.g. "(*database/sql.DB).QueryContext:2...".
+type sqlQueryMethod struct {
+	pkg      string // import path or package name, e.g. "database/sql" or "sql"
+	typeName string
+	argument sqlQueryArgument
+}
+
+// sqlQueryStructField is a field of a struct containing a query, e.g. "mypkg.Statement.SQL".
+type sqlQueryStructField struct {
+	pkg       string // import path or package name
+	typeName  string
+	fieldName string
+}
+
+var regexpSQLQueryMethod = regexp.MustCompile(`^\(\*?([^()]+)\.(\w+)\)\.(\w+)$`)
+var regexpSQLQueryStructField = regexp.MustCompile(`^([^()]+)\.(\w+)\.(\w+)$`)
+
+//------------------------------------------------------------------------------
+
+// AddFunctionName adds a function (e.g. "examplesub.Query:1") or a method by receiver type
+// (e.g. "(*database/sql.DB).QueryContext:2") taking a query as argument. If the argument index
+// is followed by "...", the query is followed by the values of its placeholders, whose number is checked.
+func (sqlqo *SQLQueryOptions) AddFunctionName(str string) error {
+	var index = strings.LastIndex(str, ":")
+	if index == -1 {
+		return fmt.Errorf("missing argument index in %s", str)
+	}
+	var argument = sqlQueryArgument{variadicArgs: strings.HasSuffix(str, "...")}
+	var err error
+	argument.index, err = strconv.Atoi(strings.TrimSuffix(str[index+1:], "..."))
+	if err != nil || argument.index < 1 {
+		return fmt.Errorf("invalid argument index in %s", str)
+	}
+	var name = str[:index]
+	if strings.HasPrefix(name, "(") {
+		var matches = regexpSQLQueryMethod.FindStringSubmatch(name)
+		if matches == nil {
+			return fmt.Errorf("invalid method %s, expected e.g. (*database/sql.DB).Query:1", str)
+		}
+		if sqlqo.MethodsNames == nil {
+			sqlqo.MethodsNames = make(map[string][]sqlQueryMethod)
+		}
+		sqlqo.MethodsNames[matches[3]] = append(sqlqo.MethodsNames[matches[3]],
+			sqlQueryMethod{pkg: matches[1], typeName: matches[2], argument: argument})
+		return nil
+	}
+	sqlqo.FunctionsNames.Add(name, argument)
+	return nil
+}
+
+// AddPreset adds the functions and methods of a well-known database library
+// ("database/sql", "sqlx", "pgx" or "gorm").
+func (sqlqo *SQLQueryOptions) AddPreset(preset string) error {
+	var names, ok = sqlQueryPresets[preset]
+	if !ok {
+		var presets []string
+		for name := range sqlQueryPresets {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+		return fmt.Errorf("unknown preset %s (known presets: %s)", preset, strings.Join(presets, ", "))
+	}
+	for _, name := range names {
+		if err := sqlqo.AddFunctionName(name); err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
+
+// AddStructField adds a field of a struct containing a query (e.g. "mypkg.Statement.SQL"): the value of this
+// field is checked in the literals of the struct and in the assignments to the field.
+func (sqlqo *SQLQueryOptions) AddStructField(str string) error {
+	var matches = regexpSQLQueryStructField.FindStringSubmatch(str)
+	if matches == nil {
+		return fmt.Errorf("invalid struct field %s, expected e.g. mypkg.Statement.SQL", str)
+	}
+	sqlqo.StructFields = append(sqlqo.StructFields, sqlQueryStructField{pkg: matches[1], typeName: matches[2], fieldName: matches[3]})
+	return nil
+}
+
+// SetLintFormat sets the format of the output of the linter: "text" (default), "phpmyadmin", "sqlfluff-json",
+// or "regex:<pattern>" with named groups among offset, line, column, severity, rule and message.
+func (sqlqo *SQLQueryOptions) SetLintFormat(format string) error {
+	var adapter, err = newSQLLintAdapter(format)
+	if err != nil {
+		return err
+	}
+	sqlqo.lintAdapter = adapter
+	return nil
+}
+
+func (sqlqo *SQLQueryOptions) isEnabled() bool {
+	return sqlqo.FunctionsNames.Count() > 0 || len(sqlqo.MethodsNames) > 0 || len(sqlqo.StructFields) > 0
 }
 
 //------------------------------------------------------------------------------
 
 type queryInfo struct {
-	strQuery string
-	filename string
+	strQuery      string
+	filename      string
+	pos           position
+	sources       sourceMap // string literals the parts of the query come from
+	incomplete    bool      // true if some parts of the query are unknown and replaced with a placeholder
+	inHTTPHandler bool      // true if the query is in an HTTP handler, for the rule missing-limit-in-handler
 }
 
 var sqlQueriesSlice []queryInfo
 
 //------------------------------------------------------------------------------
 
-const constIgnoreGoCheckDBQueries = "//!PARANO__IGNORE_CHECK_SQL_QUERIES"
-const constIgnoreGoCheckDBQueriesAlt = "// !PARANO__IGNORE_CHECK_SQL_QUERIES"
-const constIgnoreGoCheckDBQuery = "//!PARANO__IGNORE_CHECK_SQL_QUERY"
-const constIgnoreGoCheckDBQueryAlt = "// !PARANO__IGNORE_CHECK_SQL_QUERY"
+const constIgnoreGoCheckDBQueriesDirective = "IGNORE_CHECK_SQL_QUERIES"
+const constIgnoreGoCheckDBQueryDirective = "IGNORE_CHECK_SQL_QUERY"
+
+const constDisclaimerIncompleteQuery = "## Some parts of this query could not be computed and were replaced with a placeholder.\n"
 
 const constDisclaimerGoCheckDB = "## To ignore this(these) error(s) (e.g. if you think this is a false positive), put " +
-	constIgnoreGoCheckDBQuery + " or " + constIgnoreGoCheckDBQueryAlt + " in the function call,\n" +
-	"## or " + constIgnoreGoCheckDBQueries + " or " + constIgnoreGoCheckDBQueriesAlt + " on top of the function where this function call is done."
+	constDirectivePrefix + constIgnoreGoCheckDBQueryDirective + " in the function call,\n" +
+	"## or " + constDirectivePrefix + constIgnoreGoCheckDBQueriesDirective + " on top of the function where this function call is done."
 
 //------------------------------------------------------------------------------
 
-func ParanoSqllintVisit(nCaller *fileparser.Node, filename string, constantValues []fileparser.ConstValue, sqlqo SQLQueryOptions) bool {
+func ParanoSqllintVisit(nCaller *fileparser.Node, filename string, tr *typeResolver, sqlqo SQLQueryOptions) bool {
+	if nCaller != nil && nCaller.TypeStr == "ValueSpec" {
+		registerEmbeddedSQLFiles(nCaller, filename)
+		return false
+	}
 	if nCaller != nil && nCaller.TypeStr == "CallExpr" {
 
-		var value, ok = sqlqo.FunctionsNames.Find(nCaller.Name)
-		if !ok {
+		var argument sqlQueryArgument
+		if value, ok := sqlqo.FunctionsNames.Find(nCaller.Name); ok {
+			var ok2 bool
+			argument, ok2 = value.(sqlQueryArgument)
+			if !ok2 {
+				panic("value not sqlQueryArgument in sqlQueryFunctionsNames")
+			}
+		} else if argument = findSQLQueryMethod(nCaller, filename, tr, sqlqo); argument.index == 0 {
 			return false
 		}
-		var argumentIndex, ok2 = value.(int)
-		if !ok2 {
-			panic("value not int in sqlQueryFunctionsNames")
-		}
+		var argumentIndex = argument.index
 
 		// fmt.Printf("---\n")
 		var countShift = 1
@@ -69,105 +203,477 @@ func ParanoSqllintVisit(nCaller *fileparser.Node, filename string, constantValue
 			panic("bad index argument " + strconv.Itoa(countShift) + " for " + nCaller.Name)
 		}
 		var goodN = nCaller.Children[argIndex]
-		if !(goodN.TypeStr == "BinaryExpr" || goodN.TypeStr == "BasicLit") {
-			// panic("Arg " + strconv.Itoa(argumentIndex) + " (child node " + strconv.Itoa(argIndex) +
-			// 	") is not a BinaryExpr or BasicLit but " + goodN.TypeStr + " for " + nCaller.Name + ": " + nCaller.Bytes)
-			if util.IsWarn() {
-				util.Warn("File '%s': Cannot check arg %d in function call: %s", filename, argumentIndex, nCaller.Bytes)
-			}
-		}
 
-		if util.IsDebug() {
-			util.DebugPrintf("paranoSqllintVisit: %s %s %s %s", goodN.TypeStr, goodN.Name, nCaller.TypeStr, nCaller.Name)
+		var argumentCount = -1 // number of the values of the placeholders passed after the query
+		if argument.variadicArgs && !nCaller.CallHasEllipsis() {
+			argumentCount = len(nCaller.Children) - 1 - argIndex
+		}
+		return checkSQLQueryExpr(nCaller, goodN, argumentCount, filename, tr, sqlqo)
+	}
+	if nCaller != nil && (nCaller.TypeStr == "CompositeLit" || nCaller.TypeStr == "AssignStmt") && len(sqlqo.StructFields) > 0 {
+		var failed = false
+		for _, nQuery := range findSQLQueryStructFields(nCaller, tr, sqlqo) {
+			failed = checkSQLQueryExpr(nCaller, nQuery, -1, filename, tr, sqlqo) || failed
 		}
-		var strQuery, abort = goodN.ComputeStringExpression(constantValues)
-		if abort {
-			if util.IsWarn() {
+		return failed
+	}
+	return false
+}
+
+// checkSQLQueryExpr checks the query computed from the expression goodN, found in nCaller (a function
+// call, or a struct literal or an assignment for -sql-query-struct-field); argumentCount is the number
+// of the values of the placeholders, or -1 if unknown.
+func checkSQLQueryExpr(nCaller *fileparser.Node, goodN *fileparser.Node, argumentCount int, filename string, tr *typeResolver, sqlqo SQLQueryOptions) bool {
+
+	if util.IsDebug() {
+		util.DebugPrintf("paranoSqllintVisit: %s %s %s %s", goodN.TypeStr, goodN.Name, nCaller.TypeStr, nCaller.Name)
+	}
+	var qe = &queryEvaluator{tr: tr, placeholder: "?", sanitizers: sqlqo.sanitizers}
+	if sqlqo.Dialect != nil {
+		qe.placeholder = sqlqo.Dialect.Placeholder()
+	}
+	var value = qe.eval(goodN)
+	var strQuery, complete = value.str, value.complete
+	if sqlqo.ExportFile != "" {
+		exportSQLQuery(nCaller, goodN, value, filename, tr, sqlqo)
+	}
+	if !complete && strQuery == qe.placeholder && len(value.taints) == 0 {
+		if util.IsWarn() {
+			if nCaller.TypeStr == "CallExpr" {
 				util.Warn("File '%s': Cannot check query in function call %s: %s", filename, nCaller.Name, goodN.Bytes)
+			} else {
+				util.Warn("File '%s': Cannot check query in %s", filename, goodN.Bytes)
 			}
-			return false
 		}
+		return false
+	} else if !complete && util.IsInfo() {
+		util.Info("    Some parts of the SQL query in '%s' are unknown, replaced with %s: %s", filename, qe.placeholder, getStrTruncated(strQuery))
+	}
 
-		if strings.Index(nCaller.Bytes, constIgnoreGoCheckDBQuery) != -1 || strings.Index(nCaller.Bytes, constIgnoreGoCheckDBQueryAlt) != -1 {
-			if util.IsDebug() || util.IsInfo() {
-				util.Info("    Ignoring SQL query in '%s': %s", filename, getStrTruncated(strQuery))
-			}
-			return false
+	var qi = queryInfo{strQuery: strQuery, filename: filename, pos: nodePosition(filename, nCaller), sources: value.sources, incomplete: !complete}
+	if sqlqo.InjectionCheck { // only suppressed with "IGNORE sql-injection", not with the directives ignoring the query
+		checkQueryInjection(qi, value.taints)
+	}
+
+	if nCaller.ContainsDirective(constIgnoreGoCheckDBQueryDirective) {
+		if util.IsDebug() || util.IsInfo() {
+			util.Info("    Ignoring SQL query in '%s': %s", filename, getStrTruncated(strQuery))
 		}
+		return false
+	}
 
-		var nFather = nCaller.Father
-		for nFather != nil {
-			if nFather.TypeStr == "FuncDecl" {
-				for _, subn := range nFather.Children {
-					if subn.IsCommentGroupWithComment(constIgnoreGoCheckDBQueries) || subn.IsCommentGroupWithComment(constIgnoreGoCheckDBQueriesAlt) {
-						if util.IsDebug() || util.IsInfo() {
-							util.Info("    Ignoring SQL query in '%s' within function %s: %s", filename, nFather.Name, getStrTruncated(strQuery))
-						}
-						return false
+	var nFather = nCaller.Father
+	for nFather != nil {
+		if nFather.TypeStr == "FuncDecl" {
+			for _, subn := range nFather.Children {
+				if subn.IsCommentGroupWithDirective(constIgnoreGoCheckDBQueriesDirective) {
+					if util.IsDebug() || util.IsInfo() {
+						util.Info("    Ignoring SQL query in '%s' within function %s: %s", filename, nFather.Name, getStrTruncated(strQuery))
 					}
+					return false
 				}
-				//break
 			}
-			nFather = nFather.Father
+			//break
 		}
+		nFather = nFather.Father
+	}
 
-		if len(strQuery) > 0 && strQuery[len(strQuery)-1] != ';' {
-			strQuery += ";"
+	if len(sqlqo.rules) > 0 {
+		qi.inHTTPHandler = sqlqo.isInHTTPHandler(nCaller, tr)
+	}
+	if nCaller.TypeStr == "CallExpr" && (sqlqo.LintDialect != nil || sqlqo.LintBinary != "") {
+		checkQueryScan(qi, nCaller, tr, sqlqo)
+	}
+	if isEmbeddedSQLFile(value) { // e.g. "db.Exec(schemaSQL)" with "//go:embed schema.sql"
+		var statements = splitEmbeddedSQLFile(qi, sqlqo)
+		if len(statements) != 1 {
+			argumentCount = -1
 		}
-		var qi = queryInfo{strQuery: strQuery, filename: filename}
-		if sqlqo.AllInOne {
-			sqlQueriesSlice = append(sqlQueriesSlice, qi)
-		} else {
-			return checkQuery(qi, false, sqlqo.LintBinary)
+		var failed = false
+		for _, qiStatement := range statements {
+			failed = checkSQLQuery(qiStatement, argumentCount, sqlqo) || failed
 		}
+		return failed
+	}
+	return checkSQLQuery(qi, argumentCount, sqlqo)
+}
 
+// checkSQLQuery checks a query with the built-in linter, and adds it to the queries checked by the linter
+// program; argumentCount is the number of the values of the placeholders, or -1 if unknown.
+func checkSQLQuery(qi queryInfo, argumentCount int, sqlqo SQLQueryOptions) bool {
+	if len(qi.strQuery) > 0 && qi.strQuery[len(qi.strQuery)-1] != ';' {
+		qi.strQuery += ";"
+	}
+	if argumentCount != -1 && sqlqo.Dialect != nil && !qi.incomplete {
+		if checkQueryArgumentCount(qi, argumentCount, sqlqo.Dialect) {
+			return true
+		}
+	}
+	if sqlqo.LintDialect != nil {
+		if checkQueryBuiltin(qi, sqlqo.LintDialect, sqlqo.Schema) {
+			return true
+		}
 	}
+	if len(sqlqo.rules) > 0 {
+		if checkQueryRules(qi, sqlqo) {
+			return true
+		}
+	}
+	if sqlqo.LintBinary == "" {
+		return false
+	}
+	if sqlqo.Dialect != nil {
+		qi.strQuery = sqlparser.NormalizePlaceholders(qi.strQuery, sqlqo.Dialect)
+	}
+	sqlQueriesSlice = append(sqlQueriesSlice, qi) // checked by ParanoSqllintCheckQueries()
 	return false
 }
 
 //------------------------------------------------------------------------------
 
+// findSQLQueryMethod returns the position of the query if nCaller is a call to a method
+// of -sql-query-func-name, e.g. "s.db.QueryContext(ctx, q)" with "(*database/sql.DB).QueryContext:2",
+// or an index 0 otherwise.
+func findSQLQueryMethod(nCaller *fileparser.Node, filename string, tr *typeResolver, sqlqo SQLQueryOptions) (argument sqlQueryArgument) {
+
+	if len(nCaller.Children) == 0 || nCaller.Children[0].TypeStr != "SelectorExpr" {
+		return
+	}
+	var nFun = nCaller.Children[0]
+	var methods, ok = sqlqo.MethodsNames[nFun.Children[1].Name]
+	if !ok {
+		return
+	}
+	if _, isImport := tr.getImportPath(nFun.Children[0]); isImport {
+		return // this is a function of another package, e.g. "pkg.Query()"
+	}
+
+	var receiverType, ok2 = tr.exprType(nFun.Children[0])
+	if !ok2 {
+		if util.IsWarn() {
+			util.Warn("File '%s': Cannot resolve the type of the receiver in method call: %s", filename, nCaller.Bytes)
+		}
+		return
+	}
+	var types = append([]goType{receiverType}, tr.embeddedTypes(receiverType)...)
+	for _, t := range types {
+		for _, method := range methods {
+			if method.matches(t) {
+				return method.argument
+			}
+		}
+	}
+	return
+}
+
+// matches returns true if the method is declared on this type (pointer or not).
+func (method sqlQueryMethod) matches(t goType) bool {
+	return isSameType(method.pkg, method.typeName, t)
+}
+
+// isSameType returns true if t (pointer or not) is the type typeName of the package pkg (import path or name).
+func isSameType(pkg string, typeName string, t goType) bool {
+	return typeName == t.name && t.pkgPath != "" &&
+		(pkg == t.pkgPath || pkg == t.pkgName || strings.HasSuffix(t.pkgPath, "/"+pkg))
+}
+
+//------------------------------------------------------------------------------
+
+// findSQLQueryStructFields returns the values of the fields of -sql-query-struct-field in a struct literal
+// (e.g. "mypkg.Statement{SQL: q}") or in an assignment (e.g. "stmt.SQL = q").
+func findSQLQueryStructFields(n *fileparser.Node, tr *typeResolver, sqlqo SQLQueryOptions) (values []*fileparser.Node) {
+	switch n.TypeStr {
+
+	case "CompositeLit":
+		var t, ok = tr.compositeLitType(n)
+		if !ok {
+			return
+		}
+		for _, field := range sqlqo.StructFields {
+			if !isSameType(field.pkg, field.typeName, t) {
+				continue
+			}
+			var elts = n.CompositeLitElts()
+			var fieldIndex = -1
+			if len(elts) > 0 && elts[0].TypeStr != "KeyValueExpr" { // e.g. "mypkg.Statement{q, args}"
+				fieldIndex = tr.structFieldIndex(t, field.fieldName)
+			}
+			for i, nElt := range elts {
+				if nElt.TypeStr == "KeyValueExpr" {
+					if nElt.Children[0].Name == field.fieldName {
+						values = append(values, nElt.Children[1])
+					}
+				} else if i == fieldIndex {
+					values = append(values, nElt)
+				}
+			}
+		}
+
+	case "AssignStmt":
+		var lhs, rhs = n.AssignStmtLhs(), n.AssignStmtRhs()
+		if len(lhs) != len(rhs) || (n.Operator() != "=" && n.Operator() != ":=") {
+			return
+		}
+		for i, nLhs := range lhs {
+			if nLhs.TypeStr != "SelectorExpr" {
+				continue
+			}
+			for _, field := range sqlqo.StructFields {
+				if nLhs.Children[1].Name != field.fieldName {
+					continue
+				}
+				if t, ok := tr.exprType(nLhs.Children[0]); ok && isSameType(field.pkg, field.typeName, t) {
+					values = append(values, rhs[i])
+				}
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// ParanoSqllintCheckQueries runs the linter program on the queries found, one by one (with -sql-query-lint-jobs
+// linters at the same time), or all at once with -sql-query-all-in-one.
 func ParanoSqllintCheckQueries(sqlqo SQLQueryOptions) {
+	checkUnusedEmbeddedSQLFiles(sqlqo)
 	if len(sqlQueriesSlice) > 0 {
-		var sqlQueriesAll string
-		for _, qi := range sqlQueriesSlice {
-			sqlQueriesAll += qi.strQuery + "\n"
-		}
 		if util.IsInfo() {
-			util.Info("Checking %d SQL queries (%d characters)...", len(sqlQueriesSlice), len(sqlQueriesAll))
-			//fmt.Printf("%s\n", sqlQueriesAll)
+			util.Info("Checking %d SQL queries...", len(sqlQueriesSlice))
+		}
+		if sqlqo.AllInOne {
+			checkGroupOfQueries(sqlQueriesSlice, sqlqo)
+		} else {
+			var results = lintSQLQueries(sqlQueriesSlice, sqlqo)
+			for i, qi := range sqlQueriesSlice {
+				checkQuery(qi, results[i])
+			}
 		}
-		checkQuery(queryInfo{strQuery: sqlQueriesAll, filename: "???"}, true, sqlqo.LintBinary)
 		if util.IsInfo() {
 			util.Info("Checking %d SQL queries done.", len(sqlQueriesSlice))
+			if sqlqo.lintCache != nil {
+				util.Info("  Cache of the SQL linter in %s: %d hit(s), %d miss(es)", sqlqo.lintCache.dir, sqlqo.lintCache.hits, sqlqo.lintCache.misses)
+			}
 		}
 	}
+	if len(sqlRuleCounts) > 0 && sqlqo.lintAdapter.isStructured() && util.IsInfo() {
+		util.Info("SQL linter diagnostics by rule: %s", formatSQLRuleCounts())
+	}
 }
 
 //------------------------------------------------------------------------------
 
-func checkQuery(qi queryInfo, isGroupOfQueries bool, sqlQueryLintBinary string) (failed bool) {
+// checkQuery reports the errors found by the linter in a query.
+func checkQuery(qi queryInfo, result sqlLintResult) (failed bool) {
+	if result.err != nil {
+		if util.IsWarn() {
+			util.Warn("File '%s': Cannot check query with the linter program: %s: %s", getQueryLocation(qi, -1),
+				result.err.Error(), getStrTruncated(qi.strQuery))
+		}
+		return
+	} else if result.output != "" {
+		fmt.Printf("%s\n", result.output)
+	}
+	if len(result.diagnostics) == 0 {
+		return
+	}
+	countSQLDiagnostics(result.diagnostics)
+	var diagnostics = filterUnknownPartDiagnostics(qi, result.diagnostics)
+	if len(diagnostics) == 0 {
+		return
+	}
+	notPass(constCheckIDSQLQuery, qi.pos, "Invalid SQL query in %s: %s\n%s\n%s", getQueryLocation(qi, diagnostics[0].offset),
+		getStrTruncated(qi.strQuery), formatSQLDiagnostics(qi, diagnostics), getDisclaimer(qi))
+	return true
+}
+
+// checkGroupOfQueries checks all the queries at once (-sql-query-all-in-one). The errors are reported for each
+// query if the linter gives their positions.
+func checkGroupOfQueries(queries []queryInfo, sqlqo SQLQueryOptions) {
+	var text string
+	var offsets = make([]int, len(queries)) // of each query in text
+	for i, qi := range queries {
+		offsets[i] = len(text)
+		text += qi.strQuery + "\n"
+	}
+	if util.IsInfo() {
+		util.Info("  All the SQL queries together: %d characters", len(text))
+		//fmt.Printf("%s\n", text)
+	}
+
+	var runner = newSQLLintRunner(sqlqo)
+	defer runner.close()
+	var result = lintSQL(text, runner, sqlqo)
+	if result.err != nil {
+		if util.IsWarn() {
+			util.Warn("Cannot check the SQL queries with the linter program: %s", result.err.Error())
+		}
+		return
+	} else if result.output != "" {
+		fmt.Printf("%s\n", result.output)
+	}
+	countSQLDiagnostics(result.diagnostics)
+
+	var diagnosticsByQuery = make(map[int][]sqlDiagnostic)
+	var unknown []string // errors without a position
+	for _, diagnostic := range result.diagnostics {
+		if diagnostic.offset == -1 {
+			unknown = append(unknown, diagnostic.String())
+			continue
+		}
+		var i = sort.Search(len(offsets), func(i int) bool { return offsets[i] > diagnostic.offset }) - 1
+		diagnostic.offset -= offsets[i]
+		diagnosticsByQuery[i] = append(diagnosticsByQuery[i], diagnostic)
+	}
+	for i, qi := range queries {
+		if queryDiagnostics := filterUnknownPartDiagnostics(qi, diagnosticsByQuery[i]); len(queryDiagnostics) > 0 {
+			notPass(constCheckIDSQLQuery, qi.pos, "Invalid SQL query in %s: %s\n%s\n%s", getQueryLocation(qi, queryDiagnostics[0].offset),
+				getStrTruncated(qi.strQuery), formatSQLDiagnostics(qi, queryDiagnostics), getDisclaimer(qi))
+		}
+	}
+	if len(unknown) > 0 {
+		notPass(constCheckIDSQLQuery, position{filename: "???", offset: -1, line: -1},
+			"Invalid SQL queries (the position of the errors is unknown):\n%s\n%s", strings.Join(unknown, "\n"), constDisclaimerGoCheckDB)
+	}
+}
+
+// countSQLDiagnostics adds errors of the linter to the count by rule.
+func countSQLDiagnostics(diagnostics []sqlDiagnostic) {
+	for _, diagnostic := range diagnostics {
+		sqlRuleCounts[diagnostic.rule]++
+	}
+}
+
+// formatSQLDiagnostics returns the errors of the linter for a query, each one prefixed with its location
+// in the Go code.
+func formatSQLDiagnostics(qi queryInfo, diagnostics []sqlDiagnostic) string {
+	var lines []string
+	for _, diagnostic := range diagnostics {
+		lines = append(lines, getQueryLocation(qi, diagnostic.offset)+": "+diagnostic.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//------------------------------------------------------------------------------
+
+// checkQueryArgumentCount checks that the number of arguments passed after the query matches
+// the number of its placeholders.
+func checkQueryArgumentCount(qi queryInfo, argumentCount int, dialect *sqlparser.Dialect) (failed bool) {
+	var placeholders, err = sqlparser.Placeholders(qi.strQuery, dialect)
+	if err != nil {
+		return // reported by the linter
+	}
+	var expected = sqlparser.ArgumentCount(placeholders)
+	if expected == -1 || expected == argumentCount {
+		return
+	}
+	var offset = -1
+	if len(placeholders) > 0 {
+		offset = placeholders[len(placeholders)-1].Offset
+	}
+	notPass(constCheckIDSQLQuery, qi.pos, "SQL query in %s expects %d argument(s), %d passed: %s\n%s",
+		getQueryLocation(qi, offset), expected, argumentCount, getStrTruncated(qi.strQuery), constDisclaimerGoCheckDB)
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// checkQueryBuiltin checks the syntax of a query with the built-in SQL parser, and the tables and
+// the columns it uses if the schema is not nil.
+func checkQueryBuiltin(qi queryInfo, dialect *sqlparser.Dialect, schema *sqlparser.Schema) (failed bool) {
 	if util.IsDebug() {
-		util.DebugPrintf("checkQuery: %s", qi.strQuery)
+		util.DebugPrintf("checkQueryBuiltin: %s", qi.strQuery)
 	}
 
-	var sqlQueryLintBinaryWithArgs = strings.Split(sqlQueryLintBinary, " ")
-	var out, exitCode = util.RunCmdWithStdin(qi.strQuery, sqlQueryLintBinaryWithArgs[0], sqlQueryLintBinaryWithArgs[1:])
-	//fmt.Printf("out: %s\n", out)
-	if out != "" && exitCode != 0 {
-		if isGroupOfQueries {
-			util.NotPass("Invalid SQL query in %s:\n%s\n%s", qi.filename, out, constDisclaimerGoCheckDB)
-		} else {
-			util.NotPass("Invalid SQL query in %s: %s\n%s\n%s", qi.filename, getStrTruncated(qi.strQuery), out, constDisclaimerGoCheckDB)
+	var stmts, err = sqlparser.Parse(qi.strQuery, dialect)
+	if err == nil {
+		if schema != nil {
+			return checkQuerySchema(qi, stmts, schema)
+		}
+		return
+	}
+	var offset = -1
+	if errParse, ok := err.(*sqlparser.Error); ok {
+		offset = errParse.Offset
+	}
+	if isInUnknownPart(qi, offset) {
+		if util.IsWarn() {
+			util.Warn("Cannot check query in %s, error in an unknown part: %s: %s", getQueryLocation(qi, -1), err.Error(), getStrTruncated(qi.strQuery))
 		}
-		failed = true
 		return
-	} else if out != "" {
-		fmt.Printf("%s\n", out)
+	}
+	notPass(constCheckIDSQLQuery, qi.pos, "Invalid SQL query in %s: %s\n%s\n%s",
+		getQueryLocation(qi, offset), getStrTruncated(qi.strQuery), err.Error(), getDisclaimer(qi))
+	return true
+}
+
+// checkQuerySchema checks that the tables, the columns and the aliases used by a query exist in the schema.
+func checkQuerySchema(qi queryInfo, stmts []sqlparser.Statement, schema *sqlparser.Schema) (failed bool) {
+	var messages []string
+	for _, stmt := range stmts {
+		for _, err := range schema.Check(stmt) {
+			messages = append(messages, getQueryLocation(qi, err.Offset)+": "+err.Message)
+		}
+	}
+	if len(messages) == 0 {
+		return
+	}
+	notPass(constCheckIDSQLQuery, qi.pos, "SQL query does not match the schema in %s: %s\n%s\n%s",
+		getQueryLocation(qi, stmts[0].Pos()), getStrTruncated(qi.strQuery), strings.Join(messages, "\n"), getDisclaimer(qi))
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// isInUnknownPart returns true if the character at this offset of an incomplete query does not come from
+// a string literal, e.g. the placeholder replacing an unknown table name: an error there may be wrong.
+func isInUnknownPart(qi queryInfo, offset int) bool {
+	if !qi.incomplete || offset < 0 || offset >= len(qi.strQuery) {
+		return false
+	}
+	for _, segment := range qi.sources {
+		if offset >= segment.offset && offset < segment.offset+segment.length {
+			return false
+		}
+	}
+	return true
+}
+
+// filterUnknownPartDiagnostics returns the errors of the linter which are not in an unknown part of the query,
+// and warns about the other ones.
+func filterUnknownPartDiagnostics(qi queryInfo, diagnostics []sqlDiagnostic) (result []sqlDiagnostic) {
+	for _, diagnostic := range diagnostics {
+		if !isInUnknownPart(qi, diagnostic.offset) {
+			result = append(result, diagnostic)
+		} else if util.IsWarn() {
+			util.Warn("Cannot check query in %s, error in an unknown part: %s: %s", getQueryLocation(qi, -1), diagnostic.message, getStrTruncated(qi.strQuery))
+		}
 	}
 	return
 }
 
+// getDisclaimer returns the end of the message of an invalid query.
+func getDisclaimer(qi queryInfo) string {
+	if qi.incomplete {
+		return constDisclaimerIncompleteQuery + constDisclaimerGoCheckDB
+	}
+	return constDisclaimerGoCheckDB
+}
+
+// getQueryLocation returns "file:line:column" of the character at this offset in the query if it comes from
+// a string literal, or "file:line" of the function call otherwise.
+func getQueryLocation(qi queryInfo, offset int) string {
+	if offset >= 0 {
+		if location, ok := qi.sources.position(offset); ok {
+			if !strings.HasPrefix(location, qi.filename+":") { // in an embedded file
+				return fmt.Sprintf("%s (from %s:%d)", location, qi.filename, qi.pos.line)
+			}
+			return location
+		}
+	}
+	return fmt.Sprintf("%s:%d", qi.filename, qi.pos.line)
+}
+
 //------------------------------------------------------------------------------
 
 func getStrTruncated(str string) string {
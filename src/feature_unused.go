@@ -0,0 +1,40 @@
+package src
+
+import (
+	"sort"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+)
+
+//------------------------------------------------------------------------------
+
+// ParanoUnusedCheck reports the private-to-file declarations which are never used in their own file
+// (so they cannot be used anywhere).
+// Note: the //!PARANO__ directives which are not attached to anything are reported by ParanoDirectivesCheck.
+func ParanoUnusedCheck(filename string, fileInfos infosFile) {
+
+	var countByName = make(map[string]int)
+	fileInfos.rootNode.Visit(func(n *fileparser.Node) {
+		if n.TypeStr == "Ident" {
+			countByName[n.Name]++
+		}
+	})
+
+	var names = make([]string, 0, len(fileInfos.featurePrivateToFile.privateToFileDecl))
+	for name := range fileInfos.featurePrivateToFile.privateToFileDecl {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var declNode = fileInfos.featurePrivateToFile.privateToFileDecl[name]
+		if declNode.IsMethod() || name == "_" || name == "init" || (name == "main" && fileInfos.packageName == "main") {
+			continue // may be used without being referenced
+		}
+		if countByName[name] <= 1 { // the identifier in the declaration itself
+			notPass(constCheckIDUnused, nodePosition(filename, declNode), "%s is declared as private to file in %s:%d but is never used in this file", name, filename, declNode.Line)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,111 @@
+package fileparser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Directive is a //!PARANO__ comment parsed, e.g. `//!PARANO__FOO bar baz=1 qux="a b"` gives
+// Name "FOO", Args ["bar"] and Options {"baz": "1", "qux": "a b"}.
+type Directive struct {
+	Name    string
+	Args    []string
+	Options map[string]string
+}
+
+// accepts "//!PARANO__FOO", "// !PARANO__FOO", "//! PARANO__FOO", ...
+var directiveRegexp = regexp.MustCompile(`^//\s*!\s*PARANO__(\w*)(.*)$`)
+var directiveInTextRegexp = regexp.MustCompile(`//\s*!\s*PARANO__(\w+)`)
+
+//------------------------------------------------------------------------------
+
+// ParseDirective parses a comment, and returns ok=false if this is not a //!PARANO__ comment.
+func ParseDirective(commentText string) (d Directive, ok bool) {
+	var matches = directiveRegexp.FindStringSubmatch(strings.TrimSpace(commentText))
+	if matches == nil {
+		return
+	}
+	d.Name = matches[1]
+	d.Options = make(map[string]string)
+	for _, arg := range splitDirectiveArgs(matches[2]) {
+		var index = strings.Index(arg, "=")
+		if index > 0 {
+			var value = arg[index+1:]
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			d.Options[arg[:index]] = value
+		} else {
+			d.Args = append(d.Args, arg)
+		}
+	}
+	ok = true
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// splitDirectiveArgs splits on spaces, except within double quotes.
+func splitDirectiveArgs(str string) (args []string) {
+	var current strings.Builder
+	var inQuotes = false
+	for i := 0; i < len(str); i++ {
+		var c = str[i]
+		if c == '\\' && inQuotes && i+1 < len(str) {
+			current.WriteByte(c)
+			current.WriteByte(str[i+1])
+			i++
+		} else if c == '"' {
+			inQuotes = !inQuotes
+			current.WriteByte(c)
+		} else if (c == ' ' || c == '\t') && !inQuotes {
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		} else {
+			current.WriteByte(c)
+		}
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// IsCommentGroupWithDirective returns true if this is a CommentGroup with the directive in it.
+func (n *Node) IsCommentGroupWithDirective(name string) bool {
+	return n.GetCommentGroupDirective(name) != nil
+}
+
+// GetCommentGroupDirective returns the directive if this is a CommentGroup with the directive in it, or nil.
+func (n *Node) GetCommentGroupDirective(name string) *Directive {
+	if n.TypeStr == "CommentGroup" {
+		for _, child := range n.Children {
+			if d, ok := ParseDirective(child.Bytes); ok && d.Name == name {
+				return &d
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ContainsDirective returns true if the source code of this node contains the directive in a comment,
+// e.g. for a function call written on several lines.
+func (n *Node) ContainsDirective(name string) bool {
+	for _, matches := range directiveInTextRegexp.FindAllStringSubmatch(n.Bytes, -1) {
+		if matches[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package fileparser
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"strings"
 
 	"github.com/phrounz/go-parano/src/util"
@@ -15,6 +16,8 @@ type Node struct {
 	Name            string
 	BytesIndexBegin int
 	BytesIndexEnd   int
+	Line            int
+	Column          int
 	TypeStr         string
 	nodeObj         *ast.Node
 	DepthLevel      int
@@ -69,86 +72,100 @@ func (n *Node) Visit(fnCall func(*Node)) {
 
 //------------------------------------------------------------------------------
 
-// IsCommentGroupWithComment returns true if this is a CommentGroup with the comment in it.
-func (n *Node) IsCommentGroupWithComment(comment string) bool {
-	if n.TypeStr == "CommentGroup" {
-		for _, child := range n.Children {
-			if child.Bytes == comment {
-				return true
+// DeclaredNames returns the names declared by this node if this is a FuncDecl, a TypeSpec,
+// a ValueSpec (e.g. "a" and "b" for "var a, b int"), a Field, or an AssignStmt or a RangeStmt
+// with ":=", or nil otherwise.
+func (n *Node) DeclaredNames() (names []string) {
+	if n.nodeObj == nil {
+		return
+	}
+	switch d := (*n.nodeObj).(type) {
+	case *ast.FuncDecl:
+		names = append(names, d.Name.Name)
+	case *ast.TypeSpec:
+		names = append(names, d.Name.Name)
+	case *ast.ValueSpec:
+		for _, ident := range d.Names {
+			names = append(names, ident.Name)
+		}
+	case *ast.Field:
+		for _, ident := range d.Names {
+			names = append(names, ident.Name)
+		}
+	case *ast.AssignStmt:
+		if d.Tok == token.DEFINE {
+			for _, expr := range d.Lhs {
+				if ident, ok := expr.(*ast.Ident); ok {
+					names = append(names, ident.Name)
+				}
+			}
+		}
+	case *ast.RangeStmt:
+		if d.Tok == token.DEFINE {
+			for _, expr := range []ast.Expr{d.Key, d.Value} {
+				if ident, ok := expr.(*ast.Ident); ok {
+					names = append(names, ident.Name)
+				}
 			}
 		}
 	}
-	return false
+	return
 }
 
 //------------------------------------------------------------------------------
 
-// ComputeStringExpression compute/concatenate (recursively) a constant string expression,
-// e.g. "foo"+"bar"+string("baz") will return ("foobarbaz", false).
-//
-// This is a work in process, trying to compute all constants string expressions that we can know
-// at compile-time.
-//
-// If it contains something which is not processable, it will return incomplete=true and all
-// unprocessable parts will be replaced by "???" in str.
-func (n *Node) ComputeStringExpression(fileConstants []ConstValue) (str string, incomplete bool) {
-
-	switch n.TypeStr {
-
-	case "BasicLit": // "foobar"
-		str = n.Name
-		return
-
-	case "BinaryExpr": // "foo"+"bar"
-		if len(n.Children) == 2 && len(n.Bytes) > len(n.Children[0].Bytes)+len(n.Children[1].Bytes) {
-			var len0 = len(n.Children[0].Bytes)
-			var len1 = strings.Index(n.Bytes[len0:], n.Children[1].Bytes) + len0
-			var operator = n.Bytes[len0:len1]
-			operator = strings.Replace(operator, " ", "", -1)
-			operator = strings.Replace(operator, "\n", "", -1)
-			if strings.Index(operator, "+") != -1 && strings.Index(operator, "+=") == -1 {
-				var subStr1, incompleteSub1 = n.Children[0].ComputeStringExpression(fileConstants)
-				var subStr2, incompleteSub2 = n.Children[1].ComputeStringExpression(fileConstants)
-				str = subStr1 + subStr2
-				incomplete = (incompleteSub1 || incompleteSub2)
-				return
-			}
-		}
+// Operator returns the operator of an AssignStmt ("=", ":=", "+=", ...), an IncDecStmt ("++", "--"),
+// a UnaryExpr ("&", "-", ...) or a BinaryExpr ("+", "==", ...), or "" otherwise.
+func (n *Node) Operator() string {
+	if n.nodeObj == nil {
+		return ""
+	}
+	switch d := (*n.nodeObj).(type) {
+	case *ast.AssignStmt:
+		return d.Tok.String()
+	case *ast.IncDecStmt:
+		return d.Tok.String()
+	case *ast.UnaryExpr:
+		return d.Op.String()
+	case *ast.BinaryExpr:
+		return d.Op.String()
+	}
+	return ""
+}
 
-	case "CallExpr":
-		if len(n.Children) == 2 && n.Children[0].TypeStr == "Ident" && n.Children[0].Name == "string" && n.Children[1].TypeStr == "BasicLit" { // string("foobar")
-			str = n.Children[1].Name
-			return
-		}
+//------------------------------------------------------------------------------
 
-	case "Ident":
-		for _, constValue := range fileConstants {
-			if n.Name == constValue.Name && (constValue.IsTopLevel || n.IsInScope(constValue.Node)) {
-				str = constValue.Value // constFoo (being declared elsewhere in the file as 'const constFoo="foo"')
-				return
-			}
-		}
+// AssignStmtLhs returns the children of an AssignStmt which are on the left-hand side, or nil otherwise.
+func (n *Node) AssignStmtLhs() []*Node {
+	if n.nodeObj == nil {
+		return nil
 	}
+	if d, ok := (*n.nodeObj).(*ast.AssignStmt); ok && len(n.Children) >= len(d.Lhs) {
+		return n.Children[:len(d.Lhs)]
+	}
+	return nil
+}
 
-	str = "???"
-	incomplete = true
-
-	return
+// RangeStmtX returns the ranged expression of a RangeStmt (e.g. "foo" for "for _, v := range foo"), or nil otherwise.
+func (n *Node) RangeStmtX() *Node {
+	if n.nodeObj == nil {
+		return nil
+	}
+	if d, ok := (*n.nodeObj).(*ast.RangeStmt); ok {
+		return n.findAstDescendant(d.X)
+	}
+	return nil
 }
 
 //------------------------------------------------------------------------------
 
-// IsInScope returns true if "n" can access "other" i.e. the father of "other"
-// is a father or grand-father or grand-XXX-father or "n".
-func (n *Node) IsInScope(other *Node) bool {
-	if other.Father != nil {
-		var nF = n.Father
-		for nF != nil {
-			if nF == other.Father {
-				return true
-			}
-			nF = nF.Father
-		}
+// IsMethod returns true if this is a FuncDecl with a receiver.
+func (n *Node) IsMethod() bool {
+	if n.nodeObj == nil {
+		return false
+	}
+	if d, ok := (*n.nodeObj).(*ast.FuncDecl); ok {
+		return d.Recv != nil
 	}
 	return false
 }
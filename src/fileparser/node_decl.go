@@ -0,0 +1,330 @@
+package fileparser
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// ReceiverTypeName returns the name of the type of the receiver of a method (e.g. "Foo" for
+// "func (f *Foo) Bar()"), or "" if this is not a method.
+func (n *Node) ReceiverTypeName() string {
+	if n.nodeObj == nil {
+		return ""
+	}
+	if d, ok := (*n.nodeObj).(*ast.FuncDecl); ok && d.Recv != nil && len(d.Recv.List) > 0 {
+		var expr = d.Recv.List[0].Type
+		for {
+			switch e := expr.(type) {
+			case *ast.StarExpr:
+				expr = e.X
+			case *ast.ParenExpr:
+				expr = e.X
+			case *ast.IndexExpr: // generic type
+				expr = e.X
+			case *ast.Ident:
+				return e.Name
+			default:
+				return ""
+			}
+		}
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// ReceiverName returns the name of the receiver of a method (e.g. "f" for "func (f *Foo) Bar()"),
+// or "" if this is not a method or if the receiver has no name.
+func (n *Node) ReceiverName() string {
+	if n.nodeObj == nil {
+		return ""
+	}
+	if d, ok := (*n.nodeObj).(*ast.FuncDecl); ok && d.Recv != nil && len(d.Recv.List) > 0 && len(d.Recv.List[0].Names) > 0 {
+		return d.Recv.List[0].Names[0].Name
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// FuncResultTypes returns the type expressions of the results of a FuncDecl, a FuncLit or a FuncType,
+// one by result (e.g. two for "(a, b int)").
+func (n *Node) FuncResultTypes() (types []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	var funcType *ast.FuncType
+	switch d := (*n.nodeObj).(type) {
+	case *ast.FuncDecl:
+		funcType = d.Type
+	case *ast.FuncLit:
+		funcType = d.Type
+	case *ast.FuncType:
+		funcType = d
+	}
+	if funcType == nil || funcType.Results == nil {
+		return
+	}
+	for _, field := range funcType.Results.List {
+		var nType = n.findAstDescendant(field.Type)
+		if nType == nil {
+			return nil
+		}
+		var count = len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			types = append(types, nType)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// FuncParams returns the Field nodes of the parameters of a FuncDecl, a FuncLit or a FuncType.
+func (n *Node) FuncParams() (fields []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	var funcType *ast.FuncType
+	switch d := (*n.nodeObj).(type) {
+	case *ast.FuncDecl:
+		funcType = d.Type
+	case *ast.FuncLit:
+		funcType = d.Type
+	case *ast.FuncType:
+		funcType = d
+	}
+	if funcType == nil || funcType.Params == nil {
+		return
+	}
+	for _, field := range funcType.Params.List {
+		if nField := n.findAstDescendant(field); nField != nil {
+			fields = append(fields, nField)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// FieldType returns the type expression of a Field, or nil otherwise.
+func (n *Node) FieldType() *Node {
+	if n.nodeObj == nil {
+		return nil
+	}
+	if d, ok := (*n.nodeObj).(*ast.Field); ok {
+		return n.findAstDescendant(d.Type)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// StructFields returns the Field nodes of a TypeSpec declaring a struct, or of a StructType,
+// or nil otherwise. Embedded fields have no DeclaredNames().
+func (n *Node) StructFields() (fields []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	var structType *ast.StructType
+	switch d := (*n.nodeObj).(type) {
+	case *ast.TypeSpec:
+		structType, _ = d.Type.(*ast.StructType)
+	case *ast.StructType:
+		structType = d
+	}
+	if structType == nil || structType.Fields == nil {
+		return
+	}
+	for _, field := range structType.Fields.List {
+		if nField := n.findAstDescendant(field); nField != nil {
+			fields = append(fields, nField)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// ValueSpecType returns the type expression of a ValueSpec, or nil if there is none (e.g. "var a = 1").
+func (n *Node) ValueSpecType() *Node {
+	if n.nodeObj == nil {
+		return nil
+	}
+	if d, ok := (*n.nodeObj).(*ast.ValueSpec); ok && d.Type != nil {
+		return n.findAstDescendant(d.Type)
+	}
+	return nil
+}
+
+// ValueSpecValues returns the value expressions of a ValueSpec (e.g. "1" and "2" for "var a, b = 1, 2").
+func (n *Node) ValueSpecValues() (values []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	if d, ok := (*n.nodeObj).(*ast.ValueSpec); ok {
+		for _, value := range d.Values {
+			if nValue := n.findAstDescendant(value); nValue != nil {
+				values = append(values, nValue)
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// AssignStmtRhs returns the children of an AssignStmt which are on the right-hand side, or nil otherwise.
+func (n *Node) AssignStmtRhs() []*Node {
+	var lhs = n.AssignStmtLhs()
+	if lhs == nil {
+		return nil
+	}
+	return n.Children[len(lhs):]
+}
+
+//------------------------------------------------------------------------------
+
+// CallArgs returns the arguments of a CallExpr, or nil otherwise.
+func (n *Node) CallArgs() (args []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	if d, ok := (*n.nodeObj).(*ast.CallExpr); ok {
+		for _, arg := range d.Args {
+			if nArg := n.findAstDescendant(arg); nArg != nil {
+				args = append(args, nArg)
+			}
+		}
+	}
+	return
+}
+
+// CallHasEllipsis returns true for a CallExpr with "..." after its last argument, e.g. "foo(args...)".
+func (n *Node) CallHasEllipsis() bool {
+	if n.nodeObj == nil {
+		return false
+	}
+	if d, ok := (*n.nodeObj).(*ast.CallExpr); ok {
+		return d.Ellipsis.IsValid()
+	}
+	return false
+}
+
+// CompositeLitElts returns the elements of a CompositeLit (e.g. "a" and "b" for "[]string{a, b}"), or nil otherwise.
+func (n *Node) CompositeLitElts() (elts []*Node) {
+	if n.nodeObj == nil {
+		return
+	}
+	if d, ok := (*n.nodeObj).(*ast.CompositeLit); ok {
+		for _, elt := range d.Elts {
+			if nElt := n.findAstDescendant(elt); nElt != nil {
+				elts = append(elts, nElt)
+			}
+		}
+	}
+	return
+}
+
+// GoEmbedPatterns returns the patterns of the //go:embed directives of a ValueSpec (e.g. "queries/*.sql"),
+// or nil if there is none.
+func (n *Node) GoEmbedPatterns() (patterns []string) {
+	if n.nodeObj == nil {
+		return
+	}
+	var d, ok = (*n.nodeObj).(*ast.ValueSpec)
+	if !ok {
+		return
+	}
+	var docs = []*ast.CommentGroup{d.Doc}
+	if n.Father != nil && n.Father.nodeObj != nil {
+		if genDecl, ok := (*n.Father.nodeObj).(*ast.GenDecl); ok && !genDecl.Lparen.IsValid() {
+			docs = append(docs, genDecl.Doc) // "//go:embed foo\nvar bar string"
+		}
+	}
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, comment := range doc.List {
+			if !strings.HasPrefix(comment.Text, "//go:embed ") {
+				continue
+			}
+			var args = strings.TrimSpace(comment.Text[len("//go:embed "):])
+			for args != "" {
+				var pattern string
+				if args[0] == '"' || args[0] == '`' {
+					var end = strings.IndexByte(args[1:], args[0]) + 2
+					if end == 1 {
+						break // invalid
+					}
+					pattern, _ = strconv.Unquote(args[:end])
+					args = args[end:]
+				} else if index := strings.IndexAny(args, " \t"); index != -1 {
+					pattern, args = args[:index], args[index:]
+				} else {
+					pattern, args = args, ""
+				}
+				if pattern != "" {
+					patterns = append(patterns, pattern)
+				}
+				args = strings.TrimSpace(args)
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// findAstDescendant returns the node of the sub-tree matching the ast node, or nil.
+func (n *Node) findAstDescendant(obj ast.Node) *Node {
+	for _, child := range n.Children {
+		if child.nodeObj != nil && *child.nodeObj == obj {
+			return child
+		}
+	}
+	for _, child := range n.Children {
+		if found := child.findAstDescendant(obj); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// StringLiteralPosition returns the line and the column in the source file of the character at this
+// offset in the value of a string BasicLit (as computed by removeQuotes).
+func (n *Node) StringLiteralPosition(offset int) (line int, column int, ok bool) {
+	if n.TypeStr != "BasicLit" || len(n.Bytes) < 2 || (n.Bytes[0] != '"' && n.Bytes[0] != '`') {
+		return
+	}
+	var useQuotes = (n.Bytes[0] == '"')
+	var i = 1 // skip the quote
+	for k := 0; k < offset && i < len(n.Bytes)-1; k++ {
+		if useQuotes && n.Bytes[i] == '\\' {
+			i += 2
+		} else {
+			i++
+		}
+	}
+	line, column = n.Line, n.Column
+	for j := 0; j < i; j++ {
+		if n.Bytes[j] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column, true
+}
+
+//------------------------------------------------------------------------------
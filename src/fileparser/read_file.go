@@ -5,20 +5,35 @@ import (
 	"go/parser"
 	"go/token"
 	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 //------------------------------------------------------------------------------
 
 var fileBytes []byte
+var tokenFile *token.File
 
 //------------------------------------------------------------------------------
 
 // FileInfo is the output of ReadFile().
 type FileInfo struct {
-	PackageName   string
-	FileBuffer    []byte
-	RootNode      *Node
-	FileConstants []ConstValue
+	PackageName string
+	FileBuffer  []byte
+	RootNode    *Node
+	Comments    []Comment
+	Imports     map[string]string // import path by name used in the file, e.g. "sql" => "database/sql"
+}
+
+// Comment is a comment of the source file, including those which are not attached to any node.
+type Comment struct {
+	Text            string
+	BytesIndexBegin int
+	BytesIndexEnd   int
+	Line            int
+	Column          int
+	IsTrailing      bool // true if there is some code before the comment on the same line
 }
 
 //------------------------------------------------------------------------------
@@ -35,12 +50,39 @@ func ReadFile(filepath string) FileInfo {
 	if err != nil {
 		panic("could not parse file:" + err.Error())
 	}
+	tokenFile = fs.File(f.Pos())
 	v := newVisitor(f)
 	ast.Walk(&v, f)
 	var fi = FileInfo{
-		FileBuffer:    fileBytes,
-		RootNode:      v.node,
-		FileConstants: retrieveAllConstants(v.node),
+		FileBuffer: fileBytes,
+		RootNode:   v.node,
+		Imports:    make(map[string]string),
+	}
+	for _, importSpec := range f.Imports {
+		var importPath, err = strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			continue
+		}
+		var name = DefaultPackageName(importPath)
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		if name != "_" && name != "." {
+			fi.Imports[name] = importPath
+		}
+	}
+	for _, commentGroup := range f.Comments {
+		for _, comment := range commentGroup.List {
+			var position = tokenFile.Position(comment.Pos())
+			fi.Comments = append(fi.Comments, Comment{
+				Text:            comment.Text,
+				BytesIndexBegin: int(comment.Pos() - 1),
+				BytesIndexEnd:   int(comment.End() - 1),
+				Line:            position.Line,
+				Column:          position.Column,
+				IsTrailing:      isAfterCodeOnSameLine(int(comment.Pos() - 1)),
+			})
+		}
 	}
 	if len(fi.RootNode.Children) > 0 && fi.RootNode.Children[0].TypeStr == "File" &&
 		len(fi.RootNode.Children[0].Children) > 0 && fi.RootNode.Children[0].Children[0].TypeStr == "Ident" {
@@ -50,3 +92,35 @@ func ReadFile(filepath string) FileInfo {
 }
 
 //------------------------------------------------------------------------------
+
+// isAfterCodeOnSameLine returns true if there is something else than spaces between the beginning
+// of the line and this position.
+func isAfterCodeOnSameLine(bytesIndex int) bool {
+	for i := bytesIndex - 1; i >= 0 && fileBytes[i] != '\n'; i-- {
+		if fileBytes[i] != ' ' && fileBytes[i] != '\t' && fileBytes[i] != '\r' {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+var versionSuffixRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// DefaultPackageName returns the name of a package from its import path, assuming it follows the
+// usual conventions, e.g. "pgx" for "github.com/jackc/pgx/v5" or "yaml" for "gopkg.in/yaml.v2".
+func DefaultPackageName(importPath string) string {
+	var elements = strings.Split(strings.TrimSuffix(importPath, "/"), "/")
+	var name = elements[len(elements)-1]
+	if len(elements) > 1 && versionSuffixRegexp.MatchString(name) {
+		name = elements[len(elements)-2]
+	}
+	if index := strings.Index(name, ".v"); index > 0 && strings.HasPrefix(importPath, "gopkg.in/") {
+		name = name[:index]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.Replace(name, "-", "_", -1)
+}
+
+//------------------------------------------------------------------------------
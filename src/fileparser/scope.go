@@ -0,0 +1,57 @@
+package fileparser
+
+//------------------------------------------------------------------------------
+
+// FindLocalDeclaration returns the node declaring this name within the function(s) containing n
+// and visible from n (an AssignStmt with ":=", a ValueSpec, a TypeSpec, a Field for a parameter,
+// a RangeStmt with ":=" ...), or nil if there is none, e.g. if the name refers to a package-level
+// declaration.
+func (n *Node) FindLocalDeclaration(name string) *Node {
+	var child = n
+	for scope := n.Father; scope != nil && scope.TypeStr != "File"; child, scope = scope, scope.Father {
+		if scope.TypeStr == "RangeStmt" && child.TypeStr == "BlockStmt" && hasString(scope.DeclaredNames(), name) {
+			return scope
+		}
+		for _, sibling := range scope.Children {
+			if sibling == child {
+				break
+			}
+			if declNode := findDeclarationInStatement(sibling, name); declNode != nil {
+				return declNode
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// findDeclarationInStatement returns the node declaring this name if the statement n declares it, or nil.
+func findDeclarationInStatement(n *Node, name string) *Node {
+	switch n.TypeStr {
+	case "AssignStmt", "ValueSpec", "TypeSpec", "Field":
+		if hasString(n.DeclaredNames(), name) {
+			return n
+		}
+	case "DeclStmt", "GenDecl", "FieldList", "FuncType": // note: const and type declarations are flattened
+		for _, child := range n.Children {
+			if declNode := findDeclarationInStatement(child, name); declNode != nil {
+				return declNode
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func hasString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -43,11 +43,14 @@ func (v visitor) Visit(nodeObj ast.Node) ast.Visitor {
 		return nil
 	}
 
+	var position = tokenFile.Position(nodeObj.Pos())
 	var n = &Node{
 		DepthLevel:      v.depthLevel + 1,
 		Bytes:           string(fileBytes[nodeObj.Pos()-1 : nodeObj.End()-1]),
 		BytesIndexBegin: int(nodeObj.Pos() - 1),
 		BytesIndexEnd:   int(nodeObj.End() - 1),
+		Line:            position.Line,
+		Column:          position.Column,
 		nodeObj:         &nodeObj,
 		Father:          v.node,
 		TypeStr:         "(unknown)",
@@ -0,0 +1,129 @@
+package src
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlLintCache stores the outputs of the linter program on disk, by hash of the checked text (with normalized
+// whitespaces), of the command line and of the version of the linter, and of the dialect, so that the queries
+// which did not change since the previous run, or only in their whitespaces, are not linted again.
+type sqlLintCache struct {
+	dir       string
+	keyPrefix string
+	hits      int64 // atomic
+	misses    int64 // atomic
+}
+
+type sqlLintCacheEntry struct {
+	Text     string `json:"text"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+const constSQLLintCacheVersion = "2" // to change if the format of the entries or of the key changes
+
+//------------------------------------------------------------------------------
+
+// DefaultCacheDir returns the directory of the cache of go-parano in the user cache directory,
+// e.g. ~/.cache/go-parano on Linux.
+func DefaultCacheDir() (string, error) {
+	var dir, err = os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-parano"), nil
+}
+
+// SetLintCache enables the cache of the outputs of the linter program in a subdirectory of cacheDir,
+// after emptying it if clear is true. It shall be called after LintBinary and Dialect are set.
+func (sqlqo *SQLQueryOptions) SetLintCache(cacheDir string, clear bool) error {
+	var dir = filepath.Join(cacheDir, "sql-lint")
+	if clear {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var keyPrefix = "go-parano sql-lint cache " + constSQLLintCacheVersion + "\n" +
+		sqlqo.LintBinary + "\n" + getLinterVersion(sqlqo.LintBinary) + "\n" + fmt.Sprint(sqlqo.LintServer) + "\n"
+	if sqlqo.Dialect != nil {
+		keyPrefix += sqlqo.Dialect.Name + "\n"
+	}
+	sqlqo.lintCache = &sqlLintCache{dir: dir, keyPrefix: keyPrefix}
+	return nil
+}
+
+// getLinterVersion returns the path, the size and the modification time of the linter program, which
+// change when it is updated.
+func getLinterVersion(lintBinary string) string {
+	var fields = strings.Fields(lintBinary)
+	if len(fields) == 0 {
+		return ""
+	}
+	var path, err = exec.LookPath(fields[0])
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return path
+	}
+	return fmt.Sprintf("%s %d %d", path, fileInfo.Size(), fileInfo.ModTime().UnixNano())
+}
+
+//------------------------------------------------------------------------------
+
+func (cache *sqlLintCache) entryPath(text string) string {
+	var sum = sha256.Sum256([]byte(cache.keyPrefix + normalizeSQLWhitespaces(text)))
+	var hash = hex.EncodeToString(sum[:])
+	return filepath.Join(cache.dir, hash[:2], hash[2:]+".json")
+}
+
+// get returns the output of the linter for this text, if it is in the cache. The output for a text which
+// differs only in its whitespaces is returned only if it is empty, since the positions of the errors would differ.
+func (cache *sqlLintCache) get(text string) (out string, exitCode int, ok bool) {
+	var content, err = ioutil.ReadFile(cache.entryPath(text))
+	var entry sqlLintCacheEntry
+	if err != nil || json.Unmarshal(content, &entry) != nil ||
+		(entry.Text != text && (entry.ExitCode != 0 || strings.TrimSpace(entry.Output) != "")) {
+		atomic.AddInt64(&cache.misses, 1)
+		return "", 0, false
+	}
+	atomic.AddInt64(&cache.hits, 1)
+	return entry.Output, entry.ExitCode, true
+}
+
+// put stores the output of the linter for this text (errors are ignored, the cache is only an optimization).
+func (cache *sqlLintCache) put(text string, out string, exitCode int) {
+	var path = cache.entryPath(text)
+	var content, err = json.Marshal(sqlLintCacheEntry{Text: text, ExitCode: exitCode, Output: out})
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmpFile.Write(content)
+	if tmpFile.Close() != nil || err != nil || os.Rename(tmpFile.Name(), path) != nil { // atomic, for the other jobs and runs
+		os.Remove(tmpFile.Name())
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,207 @@
+package src
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/sqlparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// embeddedSQLFile is a .sql file embedded with //go:embed.
+type embeddedSQLFile struct {
+	goFile string
+	pos    position // of the variable, for the suppressions
+}
+
+// sqlEmbeddedFiles are the .sql files embedded with //go:embed, by path.
+var sqlEmbeddedFiles = map[string]*embeddedSQLFile{}
+
+// usedEmbeddedSQLFiles are the embedded files which are used as a query, and so checked where they are used.
+var usedEmbeddedSQLFiles = map[string]bool{}
+
+//------------------------------------------------------------------------------
+
+// registerEmbeddedSQLFiles records the .sql files of the //go:embed directive of a variable, which are checked
+// by checkUnusedEmbeddedSQLFiles() unless they are used as a query.
+func registerEmbeddedSQLFiles(nValueSpec *fileparser.Node, filename string) {
+	var patterns = nValueSpec.GoEmbedPatterns()
+	if len(patterns) == 0 {
+		return
+	}
+	for _, path := range resolveGoEmbedPatterns(filename, patterns) {
+		if strings.HasSuffix(path, ".sql") && sqlEmbeddedFiles[path] == nil {
+			sqlEmbeddedFiles[path] = &embeddedSQLFile{goFile: filename, pos: nodePosition(filename, nValueSpec)}
+		}
+	}
+}
+
+// resolveGoEmbedPatterns returns the files matching the patterns of a //go:embed directive in goFile, as the
+// go command does: the directories are embedded recursively, except the files beginning with "." or "_".
+func resolveGoEmbedPatterns(goFile string, patterns []string) (files []string) {
+	var dir = filepath.Dir(goFile)
+	for _, pattern := range patterns {
+		var all = strings.HasPrefix(pattern, "all:")
+		var matches, _ = filepath.Glob(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(pattern, "all:"))))
+		for _, match := range matches {
+			filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if path != match && !all && (strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "_")) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !info.IsDir() {
+					files = append(files, path)
+				}
+				return nil
+			})
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// findPackageVar returns the declaration of a package-level variable, e.g. "queries" or "pkg.Queries",
+// with an evaluator for the file where it is declared, or nil if not found.
+func (qe *queryEvaluator) findPackageVar(n *fileparser.Node) (*queryEvaluator, *fileparser.Node) {
+	switch n.TypeStr {
+	case "Ident":
+		if n.FindLocalDeclaration(n.Name) == nil {
+			if symbol := qe.tr.pkg.symbols.lookupDecl(n.Name, "var"); symbol != nil {
+				return qe.forFile(qe.tr.pkg, symbol.filename), symbol.declNode
+			}
+		}
+	case "SelectorExpr":
+		if importPath, isImport := qe.tr.getImportPath(n.Children[0]); isImport {
+			if pkg := qe.tr.findScannedPackage(importPath); pkg != nil {
+				if symbol := pkg.symbols.lookupDecl(n.Children[1].Name, "var"); symbol != nil {
+					return qe.forFile(pkg, symbol.filename), symbol.declNode
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// evalEmbeddedVar returns the content of the file embedded in a string or []byte variable with //go:embed,
+// or ok=false if this is not the case.
+func (qe *queryEvaluator) evalEmbeddedVar(n *fileparser.Node) (value queryValue, ok bool) {
+	var qeVar, valueSpec = qe.findPackageVar(n)
+	if valueSpec == nil {
+		return
+	}
+	var patterns = valueSpec.GoEmbedPatterns()
+	if len(patterns) == 0 {
+		return
+	}
+	var files = resolveGoEmbedPatterns(qeVar.tr.filename, patterns)
+	if len(files) != 1 {
+		return
+	}
+	return readEmbeddedSQLFile(files[0])
+}
+
+// evalEmbedFSReadFile returns the content of a file read from an embed.FS variable, e.g. nFS is "queries"
+// and nName is "queries/foo.sql" for `queries.ReadFile("queries/foo.sql")` or `fs.ReadFile(queries, "queries/foo.sql")`.
+func (qe *queryEvaluator) evalEmbedFSReadFile(nFS *fileparser.Node, nName *fileparser.Node) (value queryValue, ok bool) {
+	var name = qe.eval(nName)
+	if !name.complete {
+		return
+	}
+	var qeVar, valueSpec = qe.findPackageVar(nFS)
+	if valueSpec == nil {
+		return
+	}
+	var patterns = valueSpec.GoEmbedPatterns()
+	if len(patterns) == 0 {
+		return
+	}
+	var path = filepath.Join(filepath.Dir(qeVar.tr.filename), filepath.FromSlash(name.str))
+	for _, file := range resolveGoEmbedPatterns(qeVar.tr.filename, patterns) {
+		if file == path {
+			return readEmbeddedSQLFile(path)
+		}
+	}
+	return
+}
+
+// readEmbeddedSQLFile returns the content of an embedded file used as a query.
+func readEmbeddedSQLFile(path string) (value queryValue, ok bool) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	usedEmbeddedSQLFiles[path] = true
+	var text = string(content)
+	return queryValue{str: text, complete: true, sources: sourceMap{{length: len(text), filename: path, fileText: text}}}, true
+}
+
+// isEmbeddedSQLFile returns true if the value is the whole content of an embedded file.
+func isEmbeddedSQLFile(value queryValue) bool {
+	return len(value.sources) == 1 && value.sources[0].nLiteral == nil && value.sources[0].length == len(value.str)
+}
+
+//------------------------------------------------------------------------------
+
+// splitEmbeddedSQLFile returns the statements of the content of an embedded file (e.g. a schema), which are
+// checked one by one.
+func splitEmbeddedSQLFile(qi queryInfo, sqlqo SQLQueryOptions) []queryInfo {
+	var dialect = sqlqo.Dialect
+	if dialect == nil {
+		dialect, _ = sqlparser.GetDialect("mysql") // to find the ";", the syntax of the comments and the strings is enough
+	}
+	var statements, err = sqlparser.SplitStatements(qi.strQuery, dialect)
+	if err != nil || len(statements) == 0 {
+		return []queryInfo{qi} // reported by the linter
+	}
+	var result []queryInfo
+	for _, statement := range statements {
+		var qiStatement = qi
+		qiStatement.strQuery = statement.Text
+		qiStatement.sources = qi.sources.slice(statement.Offset, statement.Offset+len(statement.Text))
+		result = append(result, qiStatement)
+	}
+	return result
+}
+
+// checkUnusedEmbeddedSQLFiles checks the statements of the embedded .sql files which are not used as a query
+// (e.g. read from an embed.FS with a computed name).
+func checkUnusedEmbeddedSQLFiles(sqlqo SQLQueryOptions) {
+	var paths []string
+	for path := range sqlEmbeddedFiles {
+		if !usedEmbeddedSQLFiles[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if util.IsInfo() {
+			util.Info("  Checking embedded SQL file: %s", path)
+		}
+		var file = sqlEmbeddedFiles[path]
+		var value, ok = readEmbeddedSQLFile(path)
+		if !ok {
+			continue
+		}
+		var qi = queryInfo{strQuery: value.str, filename: file.goFile, pos: file.pos, sources: value.sources}
+		for _, qiStatement := range splitEmbeddedSQLFile(qi, sqlqo) {
+			if sqlqo.ExportFile != "" {
+				addSQLQueryExportEntry(qiStatement, "", "", sqlqo)
+			}
+			checkSQLQuery(qiStatement, -1, sqlqo)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,410 @@
+package src
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+)
+
+//------------------------------------------------------------------------------
+
+// queryEvaluator computes the value of the string expression of a query, following the constants
+// of the scanned packages, the local variables assigned once (and then only appended with "+="),
+// fmt.Sprintf() and strings.Join(). The parts which cannot be computed are replaced by a placeholder.
+type queryEvaluator struct {
+	tr          *typeResolver
+	placeholder string         // replacement of the unknown parts, e.g. "?"
+	sanitizers  *sqlSanitizers // functions whose result is not tainted, may be nil
+}
+
+// queryValue is the computed value of a string expression.
+type queryValue struct {
+	str      string
+	complete bool         // false if some parts are unknown and replaced with a placeholder
+	sources  sourceMap    // string literals the parts of str come from
+	taints   []queryTaint // unknown parts which may contain any string
+}
+
+// queryTaint is an unknown part of a query which may contain any string, e.g. a parameter
+// concatenated to the query, which allows SQL injections.
+type queryTaint struct {
+	filename string
+	n        *fileparser.Node
+}
+
+// safeBasicTypes are the types whose values, formatted in a query, cannot be an SQL injection.
+var safeBasicTypes = map[string]bool{
+	"bool": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+var regexpSprintfVerb = regexp.MustCompile(`%[-+# 0]*(\*|[0-9]*)(\.(\*|[0-9]+))?([a-zA-Z%])`)
+
+//------------------------------------------------------------------------------
+
+// concat returns the concatenation of two values.
+func (v queryValue) concat(other queryValue) queryValue {
+	return queryValue{
+		str:      v.str + other.str,
+		complete: v.complete && other.complete,
+		sources:  append(append(sourceMap{}, v.sources...), other.sources.shifted(len(v.str))...),
+		taints:   append(append([]queryTaint{}, v.taints...), other.taints...),
+	}
+}
+
+func (qe *queryEvaluator) unknown() queryValue {
+	return queryValue{str: qe.placeholder, complete: false}
+}
+
+// forFile returns an evaluator for another file, e.g. where a constant is declared.
+func (qe *queryEvaluator) forFile(pkg *packageInfos, filename string) *queryEvaluator {
+	return &queryEvaluator{tr: qe.tr.forFile(pkg, filename), placeholder: qe.placeholder, sanitizers: qe.sanitizers}
+}
+
+//------------------------------------------------------------------------------
+
+// eval returns the value of a string expression.
+func (qe *queryEvaluator) eval(n *fileparser.Node) queryValue {
+
+	if qe.tr.depth > constMaxTypeResolverDepth {
+		return qe.unknown()
+	}
+	qe.tr.depth++
+	defer func() { qe.tr.depth-- }()
+
+	switch n.TypeStr {
+
+	case "BasicLit": // "foo", `foo`, 'f', 12
+		if strings.HasPrefix(n.Bytes, "\"") || strings.HasPrefix(n.Bytes, "`") {
+			return queryValue{str: n.Name, complete: true,
+				sources: sourceMap{{length: len(n.Name), filename: qe.tr.filename, nLiteral: n}}}
+		} else if strings.HasPrefix(n.Bytes, "'") {
+			return queryValue{str: n.Name, complete: true}
+		}
+		return queryValue{str: n.Bytes, complete: true}
+
+	case "ParenExpr": // ("foo")
+		return qe.eval(n.Children[0])
+
+	case "BinaryExpr": // "foo" + bar
+		if n.Operator() == "+" && len(n.Children) == 2 {
+			return qe.evalPart(n.Children[0]).concat(qe.evalPart(n.Children[1]))
+		}
+
+	case "Ident": // foo
+		return qe.evalIdent(n)
+
+	case "SelectorExpr": // pkg.Foo
+		if importPath, isImport := qe.tr.getImportPath(n.Children[0]); isImport {
+			if pkg := qe.tr.findScannedPackage(importPath); pkg != nil {
+				if symbol := pkg.symbols.lookupDecl(n.Children[1].Name, "const"); symbol != nil {
+					return qe.forFile(pkg, symbol.filename).evalValueSpec(symbol.declNode, n.Children[1].Name)
+				}
+			}
+			if value, ok := qe.evalEmbeddedVar(n); ok { // pkg.Foo declared with //go:embed
+				return value
+			}
+		}
+
+	case "CallExpr":
+		var args = n.CallArgs()
+		if n.Children[0].TypeStr == "Ident" && n.Children[0].Name == "string" && len(args) == 1 { // string(foo)
+			return qe.eval(args[0])
+		}
+		if n.Children[0].TypeStr == "ArrayType" && n.Children[0].Bytes == "[]byte" && len(args) == 1 { // []byte(foo)
+			return qe.eval(args[0])
+		}
+		switch qe.calledFunction(n) {
+		case "fmt.Sprintf":
+			if len(args) >= 1 && !n.CallHasEllipsis() {
+				return qe.evalSprintf(args[0], args[1:])
+			}
+		case "io/fs.ReadFile": // fs.ReadFile(queries, "foo.sql")
+			if len(args) == 2 {
+				if value, ok := qe.evalEmbedFSReadFile(args[0], args[1]); ok {
+					return value
+				}
+			}
+		case "":
+			var nFun = n.Children[0]
+			if nFun.TypeStr == "SelectorExpr" && nFun.Children[1].Name == "ReadFile" && len(args) == 1 { // queries.ReadFile("foo.sql")
+				if value, ok := qe.evalEmbedFSReadFile(nFun.Children[0], args[0]); ok {
+					return value
+				}
+			}
+		case "strings.Join":
+			if len(args) == 2 {
+				if elems, ok := qe.evalStringSlice(args[0]); ok {
+					var sep = qe.evalPart(args[1])
+					var value = queryValue{complete: true}
+					for i, elem := range elems {
+						if i > 0 {
+							value = value.concat(sep)
+						}
+						value = value.concat(elem)
+					}
+					return value
+				}
+			}
+		}
+	}
+	return qe.unknown()
+}
+
+// evalPart returns the value of a part of a concatenation (or of the arguments of fmt.Sprintf() or strings.Join()),
+// which is tainted if it is unknown and may contain any string, e.g. a parameter of type string.
+func (qe *queryEvaluator) evalPart(n *fileparser.Node) queryValue {
+	var value = qe.eval(n)
+	if !value.complete && value.str == qe.placeholder && len(value.taints) == 0 && !qe.isSafe(n) {
+		value.taints = []queryTaint{{filename: qe.tr.filename, n: n}}
+	}
+	return value
+}
+
+// isSafe returns true if the value of an expression cannot be an SQL injection: a number or a boolean,
+// or the result of a sanitizer.
+func (qe *queryEvaluator) isSafe(n *fileparser.Node) bool {
+	if n.TypeStr == "ParenExpr" {
+		return qe.isSafe(n.Children[0])
+	}
+	if t, ok := qe.tr.exprType(n); ok && t.pkgPath == "" && !t.pointer && safeBasicTypes[t.name] {
+		return true
+	}
+	if n.TypeStr == "CallExpr" {
+		var nFun = n.Children[0]
+		if nFun.TypeStr == "Ident" && (nFun.Name == "len" || nFun.Name == "cap") && nFun.FindLocalDeclaration(nFun.Name) == nil {
+			return true
+		}
+		return qe.sanitizers != nil && qe.sanitizers.matches(n, qe.tr)
+	}
+	return false
+}
+
+// calledFunction returns e.g. "fmt.Sprintf" for a call to a function of an imported package, or "".
+func (qe *queryEvaluator) calledFunction(nCall *fileparser.Node) string {
+	var nFun = nCall.Children[0]
+	if nFun.TypeStr != "SelectorExpr" {
+		return ""
+	}
+	if importPath, isImport := qe.tr.getImportPath(nFun.Children[0]); isImport {
+		return importPath + "." + nFun.Children[1].Name
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// evalIdent returns the value of a constant, or of a local variable assigned once, in its declaration or
+// after a declaration without value (and then only appended with "+=" before n, the value being incomplete
+// if it is assigned or appended in another block).
+func (qe *queryEvaluator) evalIdent(n *fileparser.Node) queryValue {
+
+	var declNode = n.FindLocalDeclaration(n.Name)
+	if declNode == nil { // package-level
+		if symbol := qe.tr.pkg.symbols.lookupDecl(n.Name, "const"); symbol != nil {
+			return qe.forFile(qe.tr.pkg, symbol.filename).evalValueSpec(symbol.declNode, n.Name)
+		}
+		if value, ok := qe.evalEmbeddedVar(n); ok { // declared with //go:embed
+			return value
+		}
+		return qe.unknown()
+	}
+
+	var nValue = getDeclaredValue(declNode, n.Name)
+	var assigned = nValue != nil
+	var value queryValue
+	if assigned {
+		value = qe.eval(nValue)
+	} else if declNode.TypeStr == "ValueSpec" && len(declNode.ValueSpecValues()) == 0 {
+		value = queryValue{complete: true} // e.g. "var q string", assigned later
+	} else {
+		return qe.unknown() // e.g. a parameter
+	}
+
+	var reassigned = false
+	visitLaterAssignments(declNode, n, func(nAssign *fileparser.Node, nValue *fileparser.Node) {
+		switch {
+		case nAssign.Operator() == "+=":
+			value = value.concat(qe.evalPart(nValue))
+		case nAssign.Operator() == "=" && !assigned:
+			assigned = true
+			value = qe.eval(nValue)
+		default:
+			reassigned = true
+			return
+		}
+		if getEnclosingBlock(nAssign) != getEnclosingBlock(declNode) {
+			value.complete = false // e.g. in an if or a loop, which may not be run, or run several times
+		}
+	})
+	if reassigned {
+		return qe.unknown()
+	}
+	return value
+}
+
+// evalValueSpec returns the value of a name declared in a ValueSpec, e.g. "const foo = ...".
+func (qe *queryEvaluator) evalValueSpec(valueSpec *fileparser.Node, name string) queryValue {
+	if nValue := getDeclaredValue(valueSpec, name); nValue != nil {
+		return qe.eval(nValue)
+	}
+	return qe.unknown()
+}
+
+// evalStringSlice returns the values of the elements of a slice literal, or of a local variable
+// assigned once with a slice literal (and then only appended with "x = append(x, ...)"),
+// or ok=false if unknown.
+func (qe *queryEvaluator) evalStringSlice(n *fileparser.Node) (values []queryValue, ok bool) {
+
+	var appendValues = func(elts []*fileparser.Node) {
+		for _, elt := range elts {
+			values = append(values, qe.evalPart(elt))
+		}
+	}
+
+	switch n.TypeStr {
+	case "CompositeLit": // []string{"a", "b"}
+		appendValues(n.CompositeLitElts())
+		return values, true
+
+	case "Ident":
+		var declNode = n.FindLocalDeclaration(n.Name)
+		if declNode == nil {
+			return nil, false
+		}
+		var nValue = getDeclaredValue(declNode, n.Name)
+		if nValue == nil || nValue.TypeStr != "CompositeLit" {
+			return nil, false
+		}
+		values, _ = qe.evalStringSlice(nValue)
+		var reassigned = false
+		visitLaterAssignments(declNode, n, func(nAssign *fileparser.Node, nValue *fileparser.Node) {
+			var args = nValue.CallArgs()
+			if nAssign.Operator() == "=" && nValue.TypeStr == "CallExpr" && nValue.Children[0].Name == "append" &&
+				len(args) >= 1 && args[0].TypeStr == "Ident" && args[0].Name == n.Name && !nValue.CallHasEllipsis() {
+				appendValues(args[1:])
+			} else {
+				reassigned = true
+			}
+		})
+		return values, !reassigned
+	}
+	return nil, false
+}
+
+// evalSprintf returns the result of fmt.Sprintf(format, args...); the verbs are replaced by the values
+// of the arguments (quoted for "%q").
+func (qe *queryEvaluator) evalSprintf(nFormat *fileparser.Node, nArgs []*fileparser.Node) queryValue {
+	var format = qe.eval(nFormat)
+	if !format.complete || strings.Contains(format.str, "%[") {
+		return qe.unknown()
+	}
+	var value = queryValue{complete: true}
+	var iArg = 0
+	var previousEnd = 0
+	for _, indexes := range regexpSprintfVerb.FindAllStringIndex(format.str, -1) {
+		var verb = format.str[indexes[0]:indexes[1]]
+		value = value.concat(queryValue{str: format.str[previousEnd:indexes[0]], complete: true,
+			sources: format.sources.slice(previousEnd, indexes[0])})
+		previousEnd = indexes[1]
+		switch {
+		case verb == "%%":
+			value = value.concat(queryValue{str: "%", complete: true})
+		case strings.Contains(verb, "*"): // width or precision given as argument
+			return qe.unknown()
+		case iArg >= len(nArgs):
+			value = value.concat(qe.unknown())
+		default:
+			var arg queryValue
+			if strings.ContainsAny(verb[len(verb)-1:], "sqv") {
+				arg = qe.evalPart(nArgs[iArg])
+			} else { // e.g. "%d", whatever the argument is
+				arg = qe.eval(nArgs[iArg])
+			}
+			iArg++
+			if !arg.complete {
+				arg = queryValue{str: qe.placeholder, taints: arg.taints}
+			} else if strings.HasSuffix(verb, "q") {
+				arg = queryValue{str: strconv.Quote(arg.str), complete: true}
+			}
+			value = value.concat(arg)
+		}
+	}
+	return value.concat(queryValue{str: format.str[previousEnd:], complete: true,
+		sources: format.sources.slice(previousEnd, len(format.str))})
+}
+
+//------------------------------------------------------------------------------
+
+// getDeclaredValue returns the value of a name declared by a ValueSpec or an AssignStmt, e.g. "b"
+// for "a := b", or "f()" for "a, err := f()", or nil if there is none.
+func getDeclaredValue(declNode *fileparser.Node, name string) *fileparser.Node {
+	var names []string
+	var values []*fileparser.Node
+	switch declNode.TypeStr {
+	case "ValueSpec":
+		names, values = declNode.DeclaredNames(), declNode.ValueSpecValues()
+	case "AssignStmt":
+		for _, nLhs := range declNode.AssignStmtLhs() {
+			names = append(names, nLhs.Name)
+		}
+		values = declNode.AssignStmtRhs()
+	}
+	if len(names) > 1 && len(values) == 1 && values[0].TypeStr == "CallExpr" && names[0] == name {
+		return values[0] // the first result, e.g. of "queries.ReadFile(...)"
+	}
+	if len(names) != len(values) {
+		return nil
+	}
+	for i, declaredName := range names {
+		if declaredName == name {
+			return values[i]
+		}
+	}
+	return nil
+}
+
+// visitLaterAssignments calls fnCall for each assignment (e.g. "a = b" or "a += b") of the variable
+// declared by declNode, which is after the declaration and before nUse in the same function.
+func visitLaterAssignments(declNode *fileparser.Node, nUse *fileparser.Node, fnCall func(nAssign *fileparser.Node, nValue *fileparser.Node)) {
+	var nFunc = declNode.Father
+	for nFunc != nil && nFunc.TypeStr != "FuncDecl" && nFunc.TypeStr != "FuncLit" {
+		nFunc = nFunc.Father
+	}
+	if nFunc == nil {
+		return
+	}
+	var name = nUse.Name
+	nFunc.Visit(func(n *fileparser.Node) {
+		if n.TypeStr != "AssignStmt" || n == declNode || n.BytesIndexBegin < declNode.BytesIndexEnd ||
+			n.BytesIndexBegin >= nUse.BytesIndexBegin {
+			return
+		}
+		if n.Operator() == ":=" && n.Father != declNode.Father {
+			return // declares other variables in another block, possibly with the same name
+		}
+		var lhs, rhs = n.AssignStmtLhs(), n.AssignStmtRhs()
+		for i, nLhs := range lhs {
+			if nLhs.TypeStr == "Ident" && nLhs.Name == name && nLhs.FindLocalDeclaration(name) == declNode {
+				if len(lhs) == len(rhs) {
+					fnCall(n, rhs[i])
+				} else {
+					fnCall(n, n) // e.g. "a, b = foo()"
+				}
+			}
+		}
+	})
+}
+
+// getEnclosingBlock returns the innermost block containing n, e.g. a BlockStmt or a CaseClause.
+func getEnclosingBlock(n *fileparser.Node) *fileparser.Node {
+	var nBlock = n.Father
+	for nBlock != nil && nBlock.TypeStr != "BlockStmt" && nBlock.TypeStr != "CaseClause" && nBlock.TypeStr != "CommClause" {
+		nBlock = nBlock.Father
+	}
+	return nBlock
+}
+
+//------------------------------------------------------------------------------
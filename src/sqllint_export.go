@@ -0,0 +1,181 @@
+package src
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/sqlparser"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlQueryExportEntry is a query of the inventory written with -sql-query-export.
+type sqlQueryExportEntry struct {
+	Query         string `json:"query"`          // with the whitespaces collapsed, and the unknown parts replaced with a placeholder
+	Complete      bool   `json:"complete"`       // false if some parts of the query are unknown
+	Call          string `json:"call"`           // e.g. "s.db.QueryContext", "" for an embedded file not used in a call
+	Function      string `json:"function"`       // enclosing Go function, e.g. "store.Store.ListUsers"
+	Location      string `json:"location"`       // e.g. "store/users.go:42:18"
+	StatementType string `json:"statement_type"` // e.g. "SELECT", "" if the query cannot be parsed
+}
+
+var sqlQueryExportEntries []sqlQueryExportEntry
+
+var sqlQueryExportColumns = []string{"query", "complete", "call", "function", "location", "statement_type"}
+
+//------------------------------------------------------------------------------
+
+// SetExportFile sets the file where the inventory of the queries is written by WriteSQLQueryExport:
+// a .json or a .csv file.
+func (sqlqo *SQLQueryOptions) SetExportFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".csv":
+	default:
+		return fmt.Errorf("unknown format of %s, expected a .json or a .csv file", path)
+	}
+	sqlqo.ExportFile = path
+	return nil
+}
+
+// WriteSQLQueryExport writes the queries found to the file of -sql-query-export, if any.
+func WriteSQLQueryExport(sqlqo SQLQueryOptions) error {
+	if sqlqo.ExportFile == "" {
+		return nil
+	}
+	var file, err = os.Create(sqlqo.ExportFile)
+	if err != nil {
+		return err
+	}
+	var entries = sqlQueryExportEntries
+	if entries == nil {
+		entries = []sqlQueryExportEntry{} // "[]" rather than "null"
+	}
+	if strings.ToLower(filepath.Ext(sqlqo.ExportFile)) == ".json" {
+		var encoder = json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	} else {
+		var writer = csv.NewWriter(file)
+		writer.Write(sqlQueryExportColumns)
+		for _, entry := range entries {
+			writer.Write([]string{entry.Query, strconv.FormatBool(entry.Complete), entry.Call, entry.Function, entry.Location, entry.StatementType})
+		}
+		writer.Flush()
+		err = writer.Error()
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// exportSQLQuery adds a query computed from the expression goodN, found in nCaller, to the inventory;
+// the statements of an embedded file are added one by one.
+func exportSQLQuery(nCaller *fileparser.Node, goodN *fileparser.Node, value queryValue, filename string, tr *typeResolver, sqlqo SQLQueryOptions) {
+	var qi = queryInfo{strQuery: value.str, filename: filename, pos: nodePosition(filename, nCaller), sources: value.sources, incomplete: !value.complete}
+	var statements = []queryInfo{qi}
+	if isEmbeddedSQLFile(value) {
+		statements = splitEmbeddedSQLFile(qi, sqlqo)
+	}
+	for _, qiStatement := range statements {
+		addSQLQueryExportEntry(qiStatement, getSQLQueryCaller(nCaller, goodN), getEnclosingFunctionName(nCaller, tr.pkg.packageName), sqlqo)
+	}
+}
+
+// addSQLQueryExportEntry adds a query to the inventory.
+func addSQLQueryExportEntry(qi queryInfo, call string, function string, sqlqo SQLQueryOptions) {
+	var dialect = sqlqo.Dialect
+	if dialect == nil {
+		dialect, _ = sqlparser.GetDialect("mysql")
+	}
+	var statementTypes []string
+	if stmts, err := sqlparser.Parse(qi.strQuery, dialect); err == nil {
+		for _, stmt := range stmts {
+			statementTypes = append(statementTypes, sqlparser.StatementKind(stmt))
+		}
+	}
+	var firstOffset = len(qi.strQuery) - len(strings.TrimLeftFunc(qi.strQuery, unicode.IsSpace))
+	sqlQueryExportEntries = append(sqlQueryExportEntries, sqlQueryExportEntry{
+		Query:         normalizeSQLWhitespaces(qi.strQuery),
+		Complete:      !qi.incomplete,
+		Call:          call,
+		Function:      function,
+		Location:      getQueryLocation(qi, firstOffset),
+		StatementType: strings.Join(statementTypes, ","),
+	})
+}
+
+// getSQLQueryCaller returns e.g. "s.db.QueryContext" for a function call, "mypkg.Statement{}" for a struct
+// literal, or "stmt.SQL =" for an assignment.
+func getSQLQueryCaller(nCaller *fileparser.Node, goodN *fileparser.Node) string {
+	switch nCaller.TypeStr {
+	case "CallExpr":
+		return nCaller.Children[0].Bytes
+	case "CompositeLit":
+		if len(nCaller.Children) > 0 && (nCaller.Children[0].TypeStr == "Ident" || nCaller.Children[0].TypeStr == "SelectorExpr") {
+			return nCaller.Children[0].Bytes + "{}"
+		}
+	case "AssignStmt":
+		var lhs, rhs = nCaller.AssignStmtLhs(), nCaller.AssignStmtRhs()
+		for i, nValue := range rhs {
+			if nValue == goodN && i < len(lhs) {
+				return lhs[i].Bytes + " ="
+			}
+		}
+	}
+	return ""
+}
+
+// getEnclosingFunctionName returns e.g. "mypkg.Foo" or "mypkg.Store.Foo" for a node in the function Foo
+// (or in a function literal in Foo), or "" for a node outside any function.
+func getEnclosingFunctionName(n *fileparser.Node, packageName string) string {
+	for nFather := n.Father; nFather != nil; nFather = nFather.Father {
+		if nFather.TypeStr == "FuncDecl" {
+			if receiverTypeName := nFather.ReceiverTypeName(); receiverTypeName != "" {
+				return packageName + "." + receiverTypeName + "." + nFather.Name
+			}
+			return packageName + "." + nFather.Name
+		}
+	}
+	return ""
+}
+
+// normalizeSQLWhitespaces replaces the sequences of whitespaces of a query with a space, except in the
+// quoted strings and identifiers, and removes the ones at the beginning and at the end.
+func normalizeSQLWhitespaces(query string) string {
+	var sb strings.Builder
+	var quote rune
+	var pendingSpace, escaped = false, false
+	for _, r := range query {
+		if quote == 0 && unicode.IsSpace(r) {
+			pendingSpace = sb.Len() > 0
+			continue
+		}
+		if pendingSpace {
+			sb.WriteByte(' ')
+			pendingSpace = false
+		}
+		sb.WriteRune(r)
+		if escaped {
+			escaped = false
+		} else if quote != 0 && r == '\\' { // mysql
+			escaped = true
+		} else if quote == 0 && (r == '\'' || r == '"' || r == '`') {
+			quote = r
+		} else if r == quote {
+			quote = 0 // an escaped quote ('') is seen as the end and the beginning of a string, which is the same
+		}
+	}
+	return sb.String()
+}
+
+//------------------------------------------------------------------------------
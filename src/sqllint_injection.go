@@ -0,0 +1,99 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlSanitizers are the functions and methods whose result may be put in a query without a placeholder,
+// e.g. "strconv.Itoa" or "(pgx.Identifier).Sanitize".
+type sqlSanitizers struct {
+	functions util.WildcardMap            // by "importpath.Func" or "pkgname.Func"
+	methods   map[string][]sqlQueryMethod // by method name (the argument index is not used)
+}
+
+const constDisclaimerSQLInjection = "## Use a placeholder for this value, or if it is safe, a sanitizer function of -sql-query-sanitizers.\n" +
+	"## To ignore this error (e.g. if you think this is a false positive), put " + constDirectivePrefix + constIgnoreDirective + " " +
+	constCheckIDSQLInjection + " on top of the statement."
+
+//------------------------------------------------------------------------------
+
+// EnableInjectionCheck reports the queries in which unknown strings are concatenated or formatted
+// (e.g. "SELECT * FROM users WHERE name = '" + name + "'"), except the results of the sanitizers
+// (e.g. "strconv.Itoa" or "(pgx.Identifier).Sanitize") and of the ones of sqlSanitizerPresets.
+func (sqlqo *SQLQueryOptions) EnableInjectionCheck(sanitizers []string) error {
+	sqlqo.InjectionCheck = true
+	sqlqo.sanitizers = &sqlSanitizers{functions: util.NewWildcardMap(), methods: make(map[string][]sqlQueryMethod)}
+	for _, str := range append(append([]string{}, sqlSanitizerPresets...), sanitizers...) {
+		if strings.HasPrefix(str, "(") {
+			var matches = regexpSQLQueryMethod.FindStringSubmatch(str)
+			if matches == nil {
+				return fmt.Errorf("invalid method %s, expected e.g. (pgx.Identifier).Sanitize", str)
+			}
+			sqlqo.sanitizers.methods[matches[3]] = append(sqlqo.sanitizers.methods[matches[3]], sqlQueryMethod{pkg: matches[1], typeName: matches[2]})
+		} else if strings.Contains(str, ".") {
+			sqlqo.sanitizers.functions.Add(str, nil)
+		} else {
+			return fmt.Errorf("invalid function %s, expected e.g. strconv.Itoa", str)
+		}
+	}
+	return nil
+}
+
+// matches returns true if nCall is a call to a sanitizer.
+func (sanitizers *sqlSanitizers) matches(nCall *fileparser.Node, tr *typeResolver) bool {
+	var nFun = nCall.Children[0]
+	switch nFun.TypeStr {
+
+	case "Ident": // function of the current package
+		_, ok := sanitizers.functions.Find(tr.pkg.packageName + "." + nFun.Name)
+		return ok && nFun.FindLocalDeclaration(nFun.Name) == nil
+
+	case "SelectorExpr":
+		var name = nFun.Children[1].Name
+		if importPath, isImport := tr.getImportPath(nFun.Children[0]); isImport {
+			var _, ok1 = sanitizers.functions.Find(importPath + "." + name)
+			var _, ok2 = sanitizers.functions.Find(fileparser.DefaultPackageName(importPath) + "." + name)
+			return ok1 || ok2
+		}
+		var methods, ok = sanitizers.methods[name]
+		if !ok {
+			return false
+		}
+		var receiverType, ok2 = tr.exprType(nFun.Children[0])
+		if !ok2 {
+			return false
+		}
+		for _, t := range append([]goType{receiverType}, tr.embeddedTypes(receiverType)...) {
+			for _, method := range methods {
+				if method.matches(t) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// checkQueryInjection reports the unknown strings concatenated or formatted in a query.
+func checkQueryInjection(qi queryInfo, taints []queryTaint) (failed bool) {
+	if len(taints) == 0 {
+		return
+	}
+	var lines []string
+	for _, taint := range taints {
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s may contain any string", taint.filename, taint.n.Line, taint.n.Column, taint.n.Bytes))
+	}
+	notPass(constCheckIDSQLInjection, qi.pos, "Possible SQL injection in %s: %s\n%s\n%s",
+		getQueryLocation(qi, -1), getStrTruncated(qi.strQuery), strings.Join(lines, "\n"), constDisclaimerSQLInjection)
+	return true
+}
+
+//------------------------------------------------------------------------------
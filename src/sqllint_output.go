@@ -0,0 +1,293 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlDiagnostic is an error reported by the linter of -sql-query-lint-binary.
+type sqlDiagnostic struct {
+	offset   int    // in the text checked by the linter, -1 if unknown
+	severity string // e.g. "error" or "warning", "" if unknown
+	rule     string // e.g. "L010" for sqlfluff, "" if unknown
+	message  string
+}
+
+// sqlLintAdapter reads the output of the linter of -sql-query-lint-binary, in the format
+// of -sql-query-lint-format.
+type sqlLintAdapter struct {
+	format string         // "text" (or ""), "phpmyadmin", "sqlfluff-json" or "regex"
+	regexp *regexp.Regexp // for "regex"
+}
+
+// sqlLintFormats are the values of -sql-query-lint-format (besides "regex:<pattern>").
+var sqlLintFormats = []string{"text", "phpmyadmin", "sqlfluff-json"}
+
+// regexpLinterOffset matches the position of an error in the output of phpmyadmin's sql-parser,
+// e.g. `#1: An expression was expected. (near "FROM" at position 7)`.
+var regexpLinterOffset = regexp.MustCompile(`\bat position (\d+)`)
+
+// regexpLinterLineColumn matches the position of an error in the output of sqlfluff,
+// e.g. `L:   1 | P:   8 | PRS | Line 1, Position 8: Found unparsable section`.
+var regexpLinterLineColumn = regexp.MustCompile(`\bL:\s*(\d+)\s*\|\s*P:\s*(\d+)`)
+
+// regexpPhpmyadminError matches an error in the output of phpmyadmin's lint-query.
+var regexpPhpmyadminError = regexp.MustCompile(`^#\d+: (.*?)(?: \(near "(.*)" at position (\d+)\))?$`)
+
+//------------------------------------------------------------------------------
+
+// newSQLLintAdapter returns the adapter of a value of -sql-query-lint-format, e.g. "sqlfluff-json",
+// or "regex:<pattern>" where the pattern has named groups among offset, line, column, severity,
+// rule and message.
+func newSQLLintAdapter(format string) (sqlLintAdapter, error) {
+	if strings.HasPrefix(format, "regex:") {
+		var re, err = regexp.Compile(format[len("regex:"):])
+		if err != nil {
+			return sqlLintAdapter{}, err
+		}
+		var hasGroup = map[string]bool{}
+		for _, name := range re.SubexpNames() {
+			switch name {
+			case "offset", "line", "column", "severity", "rule", "message":
+				hasGroup[name] = true
+			case "":
+			default:
+				return sqlLintAdapter{}, fmt.Errorf("unknown group %s in regex, expected offset, line, column, severity, rule or message", name)
+			}
+		}
+		if !hasGroup["message"] && !hasGroup["rule"] {
+			return sqlLintAdapter{}, fmt.Errorf("missing group message or rule in regex, e.g. (?P<message>.*)")
+		}
+		if hasGroup["line"] != hasGroup["column"] {
+			return sqlLintAdapter{}, fmt.Errorf("groups line and column shall be used together in regex")
+		}
+		return sqlLintAdapter{format: "regex", regexp: re}, nil
+	}
+	for _, known := range sqlLintFormats {
+		if format == known {
+			return sqlLintAdapter{format: format}, nil
+		}
+	}
+	return sqlLintAdapter{}, fmt.Errorf("unknown format %s (known formats: %s, regex:<pattern>)", format, strings.Join(sqlLintFormats, ", "))
+}
+
+// isStructured returns false for the "text" format, for which any output with a nonzero exit code is an error.
+func (adapter sqlLintAdapter) isStructured() bool {
+	return adapter.format != "" && adapter.format != "text"
+}
+
+// parse returns the errors found in the output of the linter; checkedText is its standard input.
+func (adapter sqlLintAdapter) parse(out string, checkedText string) ([]sqlDiagnostic, error) {
+	switch adapter.format {
+	case "phpmyadmin":
+		return parsePhpmyadminOutput(out), nil
+	case "sqlfluff-json":
+		return parseSqlfluffJSONOutput(out, checkedText)
+	case "regex":
+		return adapter.parseRegexOutput(out, checkedText), nil
+	}
+	return parseTextOutput(out, checkedText), nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseTextOutput splits the output of any linter into errors, one per line containing the position of an error
+// (see findLinterOffset), followed by the next lines. If there is none, the whole output is a single error.
+func parseTextOutput(out string, checkedText string) (diagnostics []sqlDiagnostic) {
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		var offset = findLinterOffset(line, checkedText)
+		if offset != -1 {
+			diagnostics = append(diagnostics, sqlDiagnostic{offset: offset, message: line})
+		} else if len(diagnostics) > 0 {
+			diagnostics[len(diagnostics)-1].message += "\n" + line
+		}
+	}
+	if len(diagnostics) == 0 && strings.TrimSpace(out) != "" {
+		diagnostics = append(diagnostics, sqlDiagnostic{offset: -1, message: strings.TrimRight(out, "\n")})
+	}
+	return
+}
+
+// findLinterOffset returns the offset in the checked text of the error reported in this line of the output
+// of the linter, or -1 if there is none.
+func findLinterOffset(line string, checkedText string) int {
+	if matches := regexpLinterOffset.FindStringSubmatch(line); matches != nil {
+		var offset, err = strconv.Atoi(matches[1])
+		if err == nil && offset <= len(checkedText) {
+			return offset
+		}
+	}
+	if matches := regexpLinterLineColumn.FindStringSubmatch(line); matches != nil {
+		var lineNumber, err1 = strconv.Atoi(matches[1])
+		var column, err2 = strconv.Atoi(matches[2])
+		if err1 == nil && err2 == nil {
+			return lineColumnToOffset(checkedText, lineNumber, column)
+		}
+	}
+	return -1
+}
+
+// parsePhpmyadminOutput reads the output of phpmyadmin's lint-query, e.g.
+// `#1: An expression was expected. (near "FROM" at position 7)`.
+func parsePhpmyadminOutput(out string) (diagnostics []sqlDiagnostic) {
+	for _, line := range strings.Split(out, "\n") {
+		var matches = regexpPhpmyadminError.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		var diagnostic = sqlDiagnostic{offset: -1, severity: "error", message: matches[1]}
+		if matches[3] != "" {
+			diagnostic.offset, _ = strconv.Atoi(matches[3])
+			diagnostic.message += fmt.Sprintf(" (near %q)", matches[2])
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return
+}
+
+// sqlfluffJSONFile is a file in the output of "sqlfluff lint --format json".
+type sqlfluffJSONFile struct {
+	Violations []struct {
+		LineNo       int    `json:"line_no"`
+		LinePos      int    `json:"line_pos"`
+		StartLineNo  int    `json:"start_line_no"` // instead of line_no since sqlfluff 2.0
+		StartLinePos int    `json:"start_line_pos"`
+		Code         string `json:"code"`
+		Description  string `json:"description"`
+		Warning      bool   `json:"warning"`
+		StartFilePos *int   `json:"start_file_pos"`
+	} `json:"violations"`
+}
+
+// parseSqlfluffJSONOutput reads the output of "sqlfluff lint --format json -", skipping what is
+// before the JSON array (e.g. warnings in the standard error).
+func parseSqlfluffJSONOutput(out string, checkedText string) (diagnostics []sqlDiagnostic, err error) {
+	var index = strings.Index("\n"+out, "\n[") // first line starting with "["
+	if index == -1 {
+		return nil, fmt.Errorf("no JSON array in the output of sqlfluff")
+	}
+	var files []sqlfluffJSONFile
+	if err := json.NewDecoder(strings.NewReader(out[index:])).Decode(&files); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		for _, violation := range file.Violations {
+			var diagnostic = sqlDiagnostic{offset: -1, severity: "error", rule: violation.Code, message: violation.Description}
+			if violation.Warning {
+				diagnostic.severity = "warning"
+			}
+			switch {
+			case violation.StartFilePos != nil:
+				diagnostic.offset = *violation.StartFilePos
+			case violation.StartLineNo > 0:
+				diagnostic.offset = lineColumnToOffset(checkedText, violation.StartLineNo, violation.StartLinePos)
+			case violation.LineNo > 0:
+				diagnostic.offset = lineColumnToOffset(checkedText, violation.LineNo, violation.LinePos)
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	return
+}
+
+// parseRegexOutput reads the output of the linter with the regex of -sql-query-lint-format "regex:<pattern>".
+func (adapter sqlLintAdapter) parseRegexOutput(out string, checkedText string) (diagnostics []sqlDiagnostic) {
+	for _, matches := range adapter.regexp.FindAllStringSubmatch(out, -1) {
+		var diagnostic = sqlDiagnostic{offset: -1, severity: "error"}
+		var lineNumber, column = -1, -1
+		for i, name := range adapter.regexp.SubexpNames() {
+			var value = matches[i]
+			switch name {
+			case "offset":
+				if offset, err := strconv.Atoi(value); err == nil {
+					diagnostic.offset = offset
+				}
+			case "line":
+				lineNumber, _ = strconv.Atoi(value)
+			case "column":
+				column, _ = strconv.Atoi(value)
+			case "severity":
+				if value != "" {
+					diagnostic.severity = strings.ToLower(value)
+				}
+			case "rule":
+				diagnostic.rule = value
+			case "message":
+				diagnostic.message = strings.TrimSpace(value)
+			}
+		}
+		if lineNumber > 0 && column > 0 {
+			diagnostic.offset = lineColumnToOffset(checkedText, lineNumber, column)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// String returns e.g. "warning L010: Keywords must be consistently upper case."
+func (diagnostic sqlDiagnostic) String() string {
+	var prefix = strings.TrimSpace(diagnostic.severity + " " + diagnostic.rule)
+	if prefix == "" {
+		return diagnostic.message
+	}
+	return prefix + ": " + diagnostic.message
+}
+
+// lineColumnToOffset returns the offset of the character at this line and column (starting from 1)
+// in text, or -1 if there is none.
+func lineColumnToOffset(text string, line int, column int) int {
+	var offset = 0
+	for i := 1; i < line; i++ {
+		var index = strings.IndexByte(text[offset:], '\n')
+		if index == -1 {
+			return -1
+		}
+		offset += index + 1
+	}
+	var lineEnd = strings.IndexByte(text[offset:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(text) - offset
+	}
+	if column < 1 || column-1 > lineEnd {
+		return -1
+	}
+	return offset + column - 1
+}
+
+//------------------------------------------------------------------------------
+
+// sqlRuleCounts is the number of errors found by the linter, by rule ("" if unknown).
+var sqlRuleCounts = map[string]int{}
+
+// formatSQLRuleCounts returns e.g. "L010: 3, PRS: 1, (no rule): 2", sorted by decreasing count.
+func formatSQLRuleCounts() string {
+	var rules []string
+	for rule := range sqlRuleCounts {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if sqlRuleCounts[rules[i]] != sqlRuleCounts[rules[j]] {
+			return sqlRuleCounts[rules[i]] > sqlRuleCounts[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	var parts []string
+	for _, rule := range rules {
+		var name = rule
+		if name == "" {
+			name = "(no rule)"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %d", name, sqlRuleCounts[rule]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,155 @@
+package src
+
+import (
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlQueryPresets are the functions and methods of well-known database libraries which take a query,
+// in the same syntax as -sql-query-func-name.
+var sqlQueryPresets = map[string][]string{
+	"database/sql": {
+		"(*database/sql.DB).Query:1...", "(*database/sql.DB).QueryContext:2...",
+		"(*database/sql.DB).QueryRow:1...", "(*database/sql.DB).QueryRowContext:2...",
+		"(*database/sql.DB).Exec:1...", "(*database/sql.DB).ExecContext:2...",
+		"(*database/sql.DB).Prepare:1", "(*database/sql.DB).PrepareContext:2",
+		"(*database/sql.Tx).Query:1...", "(*database/sql.Tx).QueryContext:2...",
+		"(*database/sql.Tx).QueryRow:1...", "(*database/sql.Tx).QueryRowContext:2...",
+		"(*database/sql.Tx).Exec:1...", "(*database/sql.Tx).ExecContext:2...",
+		"(*database/sql.Tx).Prepare:1", "(*database/sql.Tx).PrepareContext:2",
+		"(*database/sql.Conn).QueryContext:2...", "(*database/sql.Conn).QueryRowContext:2...",
+		"(*database/sql.Conn).ExecContext:2...", "(*database/sql.Conn).PrepareContext:2",
+	},
+	"sqlx": {
+		"(*sqlx.DB).Select:2...", "(*sqlx.DB).Get:2...", "(*sqlx.DB).SelectContext:3...", "(*sqlx.DB).GetContext:3...",
+		"(*sqlx.DB).Queryx:1...", "(*sqlx.DB).QueryxContext:2...", "(*sqlx.DB).QueryRowx:1...", "(*sqlx.DB).QueryRowxContext:2...",
+		"(*sqlx.DB).MustExec:1...", "(*sqlx.DB).MustExecContext:2...", "(*sqlx.DB).NamedExec:1", "(*sqlx.DB).NamedQuery:1",
+		"(*sqlx.DB).Preparex:1", "(*sqlx.DB).PreparexContext:2",
+		"(*sqlx.DB).Query:1...", "(*sqlx.DB).QueryContext:2...", "(*sqlx.DB).QueryRow:1...", "(*sqlx.DB).QueryRowContext:2...",
+		"(*sqlx.DB).Exec:1...", "(*sqlx.DB).ExecContext:2...", "(*sqlx.DB).Prepare:1", "(*sqlx.DB).PrepareContext:2",
+		"(*sqlx.Tx).Select:2...", "(*sqlx.Tx).Get:2...", "(*sqlx.Tx).SelectContext:3...", "(*sqlx.Tx).GetContext:3...",
+		"(*sqlx.Tx).Queryx:1...", "(*sqlx.Tx).QueryxContext:2...", "(*sqlx.Tx).QueryRowx:1...", "(*sqlx.Tx).QueryRowxContext:2...",
+		"(*sqlx.Tx).MustExec:1...", "(*sqlx.Tx).MustExecContext:2...", "(*sqlx.Tx).NamedExec:1", "(*sqlx.Tx).NamedQuery:1",
+		"(*sqlx.Tx).Preparex:1", "(*sqlx.Tx).PreparexContext:2",
+		"(*sqlx.Tx).Query:1...", "(*sqlx.Tx).QueryContext:2...", "(*sqlx.Tx).QueryRow:1...", "(*sqlx.Tx).QueryRowContext:2...",
+		"(*sqlx.Tx).Exec:1...", "(*sqlx.Tx).ExecContext:2...", "(*sqlx.Tx).Prepare:1", "(*sqlx.Tx).PrepareContext:2",
+		"sqlx.Select:3...", "sqlx.Get:3...", "sqlx.SelectContext:4...", "sqlx.GetContext:4...",
+	},
+	"pgx": {
+		"(*pgx.Conn).Query:2...", "(*pgx.Conn).QueryRow:2...", "(*pgx.Conn).Exec:2...",
+		"(pgx.Tx).Query:2...", "(pgx.Tx).QueryRow:2...", "(pgx.Tx).Exec:2...",
+		"(*pgxpool.Pool).Query:2...", "(*pgxpool.Pool).QueryRow:2...", "(*pgxpool.Pool).Exec:2...",
+		"(*pgxpool.Conn).Query:2...", "(*pgxpool.Conn).QueryRow:2...", "(*pgxpool.Conn).Exec:2...",
+	},
+	"gorm": {
+		"(*gorm.io/gorm.DB).Raw:1", "(*gorm.io/gorm.DB).Exec:1", // no argument count check, the arguments are often named
+	},
+}
+
+// sqlSanitizerPresets are the functions and methods of the standard and database libraries whose result
+// may be put in a query without a placeholder, in the same syntax as -sql-query-sanitizers.
+var sqlSanitizerPresets = []string{
+	"strconv.Itoa", "strconv.FormatInt", "strconv.FormatUint", "strconv.FormatFloat", "strconv.FormatBool",
+	"github.com/lib/pq.QuoteIdentifier", "github.com/lib/pq.QuoteLiteral",
+	"(pgx.Identifier).Sanitize",
+}
+
+//------------------------------------------------------------------------------
+
+var knownResultTypes *util.WildcardMap
+
+// getKnownResultTypes returns the types of the results of some functions and methods of the standard
+// and database libraries, which are not in the scanned packages, by "importpath.Func" or
+// "importpath.Type.Method" (value: []goType).
+func getKnownResultTypes() *util.WildcardMap {
+	if knownResultTypes != nil {
+		return knownResultTypes
+	}
+	var m = util.NewWildcardMap()
+	var add = func(key string, types ...string) {
+		var goTypes = make([]goType, len(types))
+		for i, str := range types {
+			goTypes[i] = parseGoTypeString(str)
+		}
+		m.Add(key, goTypes)
+	}
+
+	// database/sql
+	add("database/sql.Open", "*database/sql.DB", "error")
+	add("database/sql.OpenDB", "*database/sql.DB")
+	for _, typeName := range []string{"DB", "Tx", "Conn"} {
+		var prefix = "database/sql." + typeName + "."
+		add(prefix+"Query", "*database/sql.Rows", "error")
+		add(prefix+"QueryContext", "*database/sql.Rows", "error")
+		add(prefix+"QueryRow", "*database/sql.Row")
+		add(prefix+"QueryRowContext", "*database/sql.Row")
+		add(prefix+"Prepare", "*database/sql.Stmt", "error")
+		add(prefix+"PrepareContext", "*database/sql.Stmt", "error")
+	}
+	add("database/sql.DB.Begin", "*database/sql.Tx", "error")
+	add("database/sql.DB.BeginTx", "*database/sql.Tx", "error")
+	add("database/sql.DB.Conn", "*database/sql.Conn", "error")
+	add("database/sql.Conn.BeginTx", "*database/sql.Tx", "error")
+
+	// sqlx
+	for _, pkgPath := range []string{"github.com/jmoiron/sqlx"} {
+		add(pkgPath+".Open", "*"+pkgPath+".DB", "error")
+		add(pkgPath+".Connect", "*"+pkgPath+".DB", "error")
+		add(pkgPath+".ConnectContext", "*"+pkgPath+".DB", "error")
+		add(pkgPath+".MustConnect", "*"+pkgPath+".DB")
+		add(pkgPath+".MustOpen", "*"+pkgPath+".DB")
+		add(pkgPath+".NewDb", "*"+pkgPath+".DB")
+		add(pkgPath+".DB.Beginx", "*"+pkgPath+".Tx", "error")
+		add(pkgPath+".DB.BeginTxx", "*"+pkgPath+".Tx", "error")
+		add(pkgPath+".DB.MustBegin", "*"+pkgPath+".Tx")
+		add(pkgPath+".DB.Begin", "*database/sql.Tx", "error")
+		add(pkgPath+".DB.BeginTx", "*database/sql.Tx", "error")
+	}
+
+	// pgx
+	for _, pkgPath := range []string{"github.com/jackc/pgx/v4", "github.com/jackc/pgx/v5"} {
+		add(pkgPath+".Connect", "*"+pkgPath+".Conn", "error")
+		add(pkgPath+".ConnectConfig", "*"+pkgPath+".Conn", "error")
+		add(pkgPath+".Conn.Begin", pkgPath+".Tx", "error")
+		add(pkgPath+".Conn.BeginTx", pkgPath+".Tx", "error")
+		add(pkgPath+".Tx.Begin", pkgPath+".Tx", "error")
+		var poolPath = pkgPath + "/pgxpool"
+		add(poolPath+".New", "*"+poolPath+".Pool", "error")
+		add(poolPath+".Connect", "*"+poolPath+".Pool", "error")
+		add(poolPath+".NewWithConfig", "*"+poolPath+".Pool", "error")
+		add(poolPath+".ConnectConfig", "*"+poolPath+".Pool", "error")
+		add(poolPath+".Pool.Acquire", "*"+poolPath+".Conn", "error")
+		add(poolPath+".Pool.Begin", pkgPath+".Tx", "error")
+		add(poolPath+".Pool.BeginTx", pkgPath+".Tx", "error")
+		add(poolPath+".Conn.Begin", pkgPath+".Tx", "error")
+		add(poolPath+".Conn.BeginTx", pkgPath+".Tx", "error")
+	}
+
+	// gorm (most methods of *gorm.DB return a *gorm.DB, for chaining)
+	add("gorm.io/gorm.Open", "*gorm.io/gorm.DB", "error")
+	add("gorm.io/gorm.DB.*", "*gorm.io/gorm.DB")
+	add("gorm.io/gorm.DB.Rows", "*database/sql.Rows", "error")
+	add("gorm.io/gorm.DB.Row", "*database/sql.Row")
+	add("gorm.io/gorm.DB.DB", "*database/sql.DB", "error")
+
+	// text/template and html/template, and time, for the embedded languages
+	for _, pkgPath := range []string{"text/template", "html/template"} {
+		add(pkgPath+".New", "*"+pkgPath+".Template")
+		add(pkgPath+".Must", "*"+pkgPath+".Template")
+		for _, method := range []string{"New", "Funcs", "Delims", "Option", "Lookup"} {
+			add(pkgPath+".Template."+method, "*"+pkgPath+".Template")
+		}
+	}
+	add("time.Now", "time.Time")
+	add("time.Date", "time.Time")
+	add("time.Unix", "time.Time")
+	for _, method := range []string{"UTC", "Local", "In", "Add", "AddDate", "Truncate", "Round"} {
+		add("time.Time."+method, "time.Time")
+	}
+
+	knownResultTypes = &m
+	return knownResultTypes
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,293 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/sqlparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlPolicyRule is a rule checked on the statements of the queries, in addition to their syntax,
+// e.g. "no SELECT *". Its errors can be ignored with //!PARANO__IGNORE sql-<name>.
+type sqlPolicyRule struct {
+	name        string
+	description string
+	check       func(stmt sqlparser.Statement, qi queryInfo, sqlqo SQLQueryOptions) (offset int, failed bool)
+}
+
+// sqlPolicyRules are the rules which can be enabled with EnableRules, by name.
+var sqlPolicyRules = map[string]*sqlPolicyRule{
+	"select-star": {
+		name:        "select-star",
+		description: "SELECT * shall not be used, the columns shall be listed",
+		check:       checkRuleSelectStar,
+	},
+	"missing-where": {
+		name:        "missing-where",
+		description: "UPDATE and DELETE shall have a WHERE clause",
+		check:       checkRuleMissingWhere,
+	},
+	"drop-outside-migrations": {
+		name:        "drop-outside-migrations",
+		description: "DROP and TRUNCATE shall only be used in the migration packages (see -sql-query-migration-dirs)",
+		check:       checkRuleDropOutsideMigrations,
+	},
+	"missing-limit-in-handler": {
+		name:        "missing-limit-in-handler",
+		description: "SELECT shall have a LIMIT clause in the HTTP handlers (see -sql-query-http-handler-types)",
+		check:       checkRuleMissingLimitInHandler,
+	},
+}
+
+// constCheckIDSQLRulePrefix is the prefix of the check identifiers of the rules, e.g. "sql-select-star".
+const constCheckIDSQLRulePrefix = "sql-"
+
+var regexpSQLHandlerType = regexp.MustCompile(`^\*?([^()*]+)\.(\w+)$`)
+
+var sqlAggregateFunctions = map[string]bool{"COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true}
+
+//------------------------------------------------------------------------------
+
+// EnableRules enables the policy rules of sqlPolicyRules with these names, or all of them with "all".
+func (sqlqo *SQLQueryOptions) EnableRules(names []string) error {
+	for _, name := range names {
+		if name == "all" {
+			sqlqo.rules = nil
+			for _, ruleName := range getSQLPolicyRuleNames() {
+				sqlqo.rules = append(sqlqo.rules, sqlPolicyRules[ruleName])
+			}
+			return nil
+		}
+		var rule, ok = sqlPolicyRules[name]
+		if !ok {
+			return fmt.Errorf("unknown rule %s (known rules: %s)", name, strings.Join(getSQLPolicyRuleNames(), ", "))
+		}
+		sqlqo.rules = append(sqlqo.rules, rule)
+	}
+	return nil
+}
+
+// SetMigrationDirs sets the directories of the migration packages, in which DROP and TRUNCATE are allowed,
+// e.g. "migration*": a package is a migration package if one of its directories matches one of them.
+func (sqlqo *SQLQueryOptions) SetMigrationDirs(dirs []string) {
+	sqlqo.migrationDirs = util.NewWildcardMap()
+	for _, dir := range dirs {
+		if dir != "" {
+			sqlqo.migrationDirs.Add(dir, nil)
+		}
+	}
+}
+
+// SetHTTPHandlerTypes sets the types of the parameters of the HTTP handlers, e.g. "net/http.Request"
+// or "github.com/gin-gonic/gin.Context" (pointer or not).
+func (sqlqo *SQLQueryOptions) SetHTTPHandlerTypes(types []string) error {
+	sqlqo.httpHandlerTypes = nil
+	for _, str := range types {
+		if str == "" {
+			continue
+		}
+		var matches = regexpSQLHandlerType.FindStringSubmatch(str)
+		if matches == nil {
+			return fmt.Errorf("invalid type %s, expected e.g. net/http.Request", str)
+		}
+		sqlqo.httpHandlerTypes = append(sqlqo.httpHandlerTypes, sqlQueryMethod{pkg: matches[1], typeName: matches[2]})
+	}
+	return nil
+}
+
+func getSQLPolicyRuleNames() (names []string) {
+	for name := range sqlPolicyRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// getSQLPolicyRuleCheckIDs returns the check identifiers of the rules, for //!PARANO__IGNORE.
+func getSQLPolicyRuleCheckIDs() (checkIDs []string) {
+	for _, name := range getSQLPolicyRuleNames() {
+		checkIDs = append(checkIDs, constCheckIDSQLRulePrefix+name)
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// checkQueryRules checks the enabled policy rules on the statements of a query; the queries which cannot
+// be parsed are not checked (the syntax errors are reported by the linters).
+func checkQueryRules(qi queryInfo, sqlqo SQLQueryOptions) (failed bool) {
+	var dialect = sqlqo.LintDialect
+	if dialect == nil {
+		dialect = sqlqo.Dialect
+	}
+	if dialect == nil {
+		dialect, _ = sqlparser.GetDialect("mysql")
+	}
+	var stmts, err = sqlparser.Parse(qi.strQuery, dialect)
+	if err != nil {
+		if util.IsInfo() {
+			util.Info("    Cannot check the rules of the SQL query in '%s': %s", qi.filename, err.Error())
+		}
+		return
+	}
+	for _, rule := range sqlqo.rules {
+		for _, stmt := range stmts {
+			if offset, ruleFailed := rule.check(stmt, qi, sqlqo); ruleFailed {
+				var checkID = constCheckIDSQLRulePrefix + rule.name
+				notPass(checkID, qi.pos, "SQL query does not follow the rule %s in %s: %s\n%s: %s\n%s",
+					rule.name, getQueryLocation(qi, offset), getStrTruncated(qi.strQuery),
+					sqlparser.StatementKind(stmt), rule.description, getRuleDisclaimer(checkID))
+				failed = true
+			}
+		}
+	}
+	return
+}
+
+func getRuleDisclaimer(checkID string) string {
+	return "## To ignore this error (e.g. if you think this is a false positive), put " + constDirectivePrefix +
+		constIgnoreDirective + " " + checkID + " on top of the statement."
+}
+
+//------------------------------------------------------------------------------
+
+// checkRuleSelectStar reports the "*" in the columns of a SELECT, of the parts of a UNION, of the
+// WITH clauses and of the subqueries of FROM (but not in "EXISTS (SELECT * ...)", nor "COUNT(*)").
+func checkRuleSelectStar(stmt sqlparser.Statement, qi queryInfo, sqlqo SQLQueryOptions) (offset int, failed bool) {
+	var offsetStar = -1
+	var visitSelect func(s *sqlparser.SelectStmt)
+	var visitTableRefs func(refs []*sqlparser.TableRef)
+	var visitWith = func(ctes []*sqlparser.CommonTableExpr) {
+		for _, cte := range ctes {
+			if s, ok := cte.Stmt.(*sqlparser.SelectStmt); ok {
+				visitSelect(s)
+			}
+		}
+	}
+	visitTableRefs = func(refs []*sqlparser.TableRef) {
+		for _, ref := range refs {
+			if ref.Subquery != nil {
+				visitSelect(ref.Subquery)
+			}
+			visitTableRefs(ref.Nested)
+		}
+	}
+	visitSelect = func(s *sqlparser.SelectStmt) {
+		if s == nil {
+			return
+		}
+		visitWith(s.With)
+		for _, column := range s.Columns {
+			if column.Star && offsetStar == -1 {
+				offsetStar = column.Offset
+			}
+		}
+		visitTableRefs(s.From)
+		if s.Compound != nil {
+			visitSelect(s.Compound.Select)
+		}
+	}
+	switch s := stmt.(type) {
+	case *sqlparser.SelectStmt:
+		visitSelect(s)
+	case *sqlparser.InsertStmt:
+		visitWith(s.With)
+		visitSelect(s.Select)
+	case *sqlparser.CreateViewStmt:
+		visitSelect(s.Select)
+	case *sqlparser.CreateTableStmt:
+		visitSelect(s.AsSelect)
+	}
+	return offsetStar, offsetStar != -1
+}
+
+// checkRuleMissingWhere reports the UPDATE and DELETE without WHERE.
+func checkRuleMissingWhere(stmt sqlparser.Statement, qi queryInfo, sqlqo SQLQueryOptions) (offset int, failed bool) {
+	switch s := stmt.(type) {
+	case *sqlparser.UpdateStmt:
+		return s.Offset, s.Where == nil
+	case *sqlparser.DeleteStmt:
+		return s.Offset, s.Where == nil
+	}
+	return
+}
+
+// checkRuleDropOutsideMigrations reports the DROP, the ALTER TABLE ... DROP COLUMN and the TRUNCATE
+// outside the migration packages.
+func checkRuleDropOutsideMigrations(stmt sqlparser.Statement, qi queryInfo, sqlqo SQLQueryOptions) (offset int, failed bool) {
+	switch s := stmt.(type) {
+	case *sqlparser.DropStmt, *sqlparser.TruncateStmt:
+	case *sqlparser.AlterTableStmt:
+		if len(s.DropColumns) == 0 {
+			return
+		}
+	default:
+		return
+	}
+	return stmt.Pos(), !sqlqo.isInMigrationDir(qi.filename)
+}
+
+// checkRuleMissingLimitInHandler reports the SELECT without LIMIT in the HTTP handlers, except the ones
+// which return one row (without FROM, or with only aggregate functions and no GROUP BY).
+func checkRuleMissingLimitInHandler(stmt sqlparser.Statement, qi queryInfo, sqlqo SQLQueryOptions) (offset int, failed bool) {
+	var s, ok = stmt.(*sqlparser.SelectStmt)
+	if !ok || !qi.inHTTPHandler || s.Limit != nil || len(s.From) == 0 {
+		return
+	}
+	if s.Compound == nil && len(s.GroupBy) == 0 {
+		var aggregatesOnly = true
+		for _, column := range s.Columns {
+			var call, isCall = column.Expr.(*sqlparser.FuncCall)
+			aggregatesOnly = aggregatesOnly && isCall && sqlAggregateFunctions[call.Name]
+		}
+		if aggregatesOnly {
+			return
+		}
+	}
+	return s.Offset, true
+}
+
+//------------------------------------------------------------------------------
+
+// isInMigrationDir returns true if one of the directories of a file matches -sql-query-migration-dirs.
+func (sqlqo *SQLQueryOptions) isInMigrationDir(filename string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(filename)), "/") {
+		if _, ok := sqlqo.migrationDirs.Find(dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isInHTTPHandler returns true if n is in a function (or a function literal) which has a parameter of
+// a type of -sql-query-http-handler-types, e.g. "func(w http.ResponseWriter, r *http.Request)".
+func (sqlqo *SQLQueryOptions) isInHTTPHandler(n *fileparser.Node, tr *typeResolver) bool {
+	for nFather := n.Father; nFather != nil; nFather = nFather.Father {
+		if nFather.TypeStr != "FuncDecl" && nFather.TypeStr != "FuncLit" {
+			continue
+		}
+		for _, nField := range nFather.FuncParams() {
+			var nType = nField.FieldType()
+			if nType == nil {
+				continue
+			}
+			if t, ok := tr.typeExprType(nType); ok {
+				for _, handlerType := range sqlqo.httpHandlerTypes {
+					if handlerType.matches(t) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,205 @@
+package src
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlLintRunner runs the linter program of -sql-query-lint-binary, either once per text to check, or once
+// for all of them with -sql-query-lint-server. It is used by a single goroutine.
+type sqlLintRunner struct {
+	command []string
+	timeout time.Duration // 0: no timeout
+	server  bool
+	process *sqlLintServerProcess // nil if not started yet, or stopped after an error
+}
+
+// sqlLintServerProcess is a linter program started with -sql-query-lint-server. It reads one query per line
+// as a JSON string, e.g. "SELECT 1;\n", and replies one line per query with a JSON object,
+// e.g. {"exit_code": 1, "output": "..."}.
+type sqlLintServerProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan sqlLintServerReply // closed when the process exits
+}
+
+type sqlLintServerReply struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+	err      error
+}
+
+// sqlLintResult is the result of the linter for a text to check.
+type sqlLintResult struct {
+	diagnostics []sqlDiagnostic
+	output      string // output which is not an error (exit code 0, "text" format), to be printed
+	err         error  // the linter could not be run
+}
+
+//------------------------------------------------------------------------------
+
+func newSQLLintRunner(sqlqo SQLQueryOptions) *sqlLintRunner {
+	return &sqlLintRunner{command: strings.Fields(sqlqo.LintBinary), timeout: sqlqo.LintTimeout, server: sqlqo.LintServer}
+}
+
+// run runs the linter with text as input, and returns its output and its exit code.
+func (runner *sqlLintRunner) run(text string) (out string, exitCode int, err error) {
+	if !runner.server {
+		return util.RunCmdWithStdin(text, runner.timeout, runner.command[0], runner.command[1:])
+	}
+	if runner.process == nil {
+		if runner.process, err = startSQLLintServer(runner.command); err != nil {
+			return "", -1, err
+		}
+	}
+	var request, _ = json.Marshal(text)
+	if _, err = runner.process.stdin.Write(append(request, '\n')); err != nil {
+		runner.close()
+		return "", -1, fmt.Errorf("cannot write to %s: %s", runner.command[0], err.Error())
+	}
+	var timeout <-chan time.Time
+	if runner.timeout > 0 {
+		timeout = time.After(runner.timeout)
+	}
+	select {
+	case reply, ok := <-runner.process.replies:
+		if !ok {
+			runner.close()
+			return "", -1, fmt.Errorf("%s exited without replying", runner.command[0])
+		} else if reply.err != nil {
+			runner.close()
+			return "", -1, reply.err
+		}
+		return reply.Output, reply.ExitCode, nil
+	case <-timeout:
+		runner.close() // restarted for the next query
+		return "", -1, fmt.Errorf("%s killed after a timeout of %s", runner.command[0], runner.timeout)
+	}
+}
+
+// close stops the linter started with -sql-query-lint-server, if any.
+func (runner *sqlLintRunner) close() {
+	if runner.process != nil {
+		runner.process.stdin.Close()
+		runner.process.cmd.Process.Kill()
+		runner.process.cmd.Wait()
+		runner.process = nil
+	}
+}
+
+// startSQLLintServer starts a linter for -sql-query-lint-server.
+func startSQLLintServer(command []string) (*sqlLintServerProcess, error) {
+	var cmd = exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+	var stdin, err = cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot run %s: %s", command[0], err.Error())
+	}
+	var process = &sqlLintServerProcess{cmd: cmd, stdin: stdin, replies: make(chan sqlLintServerReply, 1)}
+	go func() {
+		defer close(process.replies)
+		var reader = bufio.NewReader(stdout)
+		for {
+			var line, err = reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var reply sqlLintServerReply
+			if err := json.Unmarshal([]byte(line), &reply); err != nil {
+				reply.err = fmt.Errorf("invalid reply of %s: %s: %s", command[0], err.Error(), strings.TrimSpace(line))
+			}
+			process.replies <- reply
+		}
+	}()
+	return process, nil
+}
+
+//------------------------------------------------------------------------------
+
+// lintSQL runs the linter on a text (unless its output is in the cache), and returns the errors it reports, except the ignored rules.
+// With the "text" format, the output is an error if the exit code is nonzero, and is printed otherwise.
+func lintSQL(text string, runner *sqlLintRunner, sqlqo SQLQueryOptions) (result sqlLintResult) {
+	var out, exitCode, cached = "", 0, false
+	var err error
+	if sqlqo.lintCache != nil {
+		out, exitCode, cached = sqlqo.lintCache.get(text)
+	}
+	if !cached {
+		out, exitCode, err = runner.run(text)
+		if err == nil && sqlqo.lintCache != nil {
+			sqlqo.lintCache.put(text, out, exitCode)
+		}
+	}
+	if err != nil {
+		result.err = err
+		return
+	} else if out == "" {
+		return
+	} else if exitCode == 0 && !sqlqo.lintAdapter.isStructured() {
+		result.output = out
+		return
+	}
+
+	all, err := sqlqo.lintAdapter.parse(out, text)
+	if (err != nil || len(all) == 0) && exitCode != 0 { // e.g. the linter failed
+		all = []sqlDiagnostic{{offset: -1, message: strings.TrimRight(out, "\n")}}
+	}
+	for _, diagnostic := range all {
+		if _, ignored := sqlqo.IgnoredRules.Find(diagnostic.rule); ignored && diagnostic.rule != "" {
+			continue
+		}
+		result.diagnostics = append(result.diagnostics, diagnostic)
+	}
+	return
+}
+
+// lintSQLQueries runs the linter on each query, with -sql-query-lint-jobs linters at the same time.
+func lintSQLQueries(queries []queryInfo, sqlqo SQLQueryOptions) []sqlLintResult {
+	var results = make([]sqlLintResult, len(queries))
+	var jobs = sqlqo.LintJobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	var indexes = make(chan int)
+	var wg sync.WaitGroup
+	for j := 0; j < jobs; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var runner = newSQLLintRunner(sqlqo)
+			defer runner.close()
+			for i := range indexes {
+				if util.IsDebug() {
+					util.DebugPrintf("checkQuery: %s", queries[i].strQuery)
+				}
+				results[i] = lintSQL(queries[i].strQuery, runner, sqlqo)
+			}
+		}()
+	}
+	for i := range queries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,203 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/sqlparser"
+)
+
+//------------------------------------------------------------------------------
+
+const constDisclaimerSQLScan = "## To ignore this error (e.g. if you think this is a false positive), put " + constDirectivePrefix +
+	constIgnoreDirective + " " + constCheckIDSQLScan + " on top of the statement."
+
+// sqlTypeCategories are the categories of the SQL types, by prefix of the type (e.g. "VARCHAR" for "VARCHAR(255)"),
+// to compare them with the Go types of the destinations of Scan().
+var sqlTypeCategories = map[string]string{
+	"CHAR": "text", "VARCHAR": "text", "NCHAR": "text", "NVARCHAR": "text", "TEXT": "text", "TINYTEXT": "text",
+	"MEDIUMTEXT": "text", "LONGTEXT": "text", "CHARACTER": "text", "CLOB": "text", "UUID": "text", "ENUM": "text",
+	"INT": "integer", "INTEGER": "integer", "TINYINT": "integer", "SMALLINT": "integer", "MEDIUMINT": "integer",
+	"BIGINT": "integer", "SERIAL": "integer", "BIGSERIAL": "integer", "SMALLSERIAL": "integer",
+	"FLOAT": "float", "DOUBLE": "float", "REAL": "float", "DECIMAL": "float", "NUMERIC": "float",
+	"BOOL": "bool", "BOOLEAN": "bool",
+	"DATE": "time", "DATETIME": "time", "TIMESTAMP": "time", "TIMESTAMPTZ": "time", "TIME": "time",
+}
+
+// goScanCategories are the categories of the SQL types which can be scanned into a Go type; the Go types
+// which are not listed accept any column (e.g. string, []byte, interface{} or a sql.Scanner).
+var goScanCategories = map[string][]string{
+	"int": {"integer"}, "int8": {"integer"}, "int16": {"integer"}, "int32": {"integer"}, "int64": {"integer"},
+	"uint": {"integer"}, "uint8": {"integer"}, "uint16": {"integer"}, "uint32": {"integer"}, "uint64": {"integer"},
+	"float32": {"integer", "float"}, "float64": {"integer", "float"},
+	"bool":                   {"bool", "integer"},
+	"time.Time":              {"time"},
+	"database/sql.NullInt64": {"integer"}, "database/sql.NullInt32": {"integer"}, "database/sql.NullInt16": {"integer"},
+	"database/sql.NullByte": {"integer"}, "database/sql.NullFloat64": {"integer", "float"},
+	"database/sql.NullBool": {"bool", "integer"}, "database/sql.NullTime": {"time"},
+}
+
+//------------------------------------------------------------------------------
+
+// checkQueryScan compares the columns returned by a query with the destinations of the calls to Scan()
+// on the result of nCall, e.g. "db.QueryRow(q).Scan(&a, &b)", or "rows.Scan(&a, &b)" after
+// "rows, err := db.Query(q)" in the same function; and their types if the schema is known.
+func checkQueryScan(qi queryInfo, nCall *fileparser.Node, tr *typeResolver, sqlqo SQLQueryOptions) (failed bool) {
+	if qi.incomplete {
+		return
+	}
+	var nScans = findScanCalls(nCall)
+	if len(nScans) == 0 {
+		return
+	}
+	var dialect = sqlqo.LintDialect
+	if dialect == nil {
+		dialect = sqlqo.Dialect
+	}
+	if dialect == nil {
+		dialect, _ = sqlparser.GetDialect("mysql")
+	}
+	var stmts, err = sqlparser.Parse(qi.strQuery, dialect)
+	if err != nil || len(stmts) != 1 {
+		return // reported by the linters
+	}
+	var columns, ok = sqlparser.ResultColumns(stmts[0], sqlqo.Schema)
+	if !ok {
+		return
+	}
+	for _, nScan := range nScans {
+		var args = nScan.CallArgs()
+		if nScan.CallHasEllipsis() {
+			continue
+		}
+		var pos = nodePosition(qi.filename, nScan)
+		if len(args) != len(columns) {
+			notPass(constCheckIDSQLScan, pos, "SQL query in %s returns %d column(s), but %d destination(s) are passed to Scan() in %s:%d: %s\n%s",
+				getQueryLocation(qi, stmts[0].Pos()), len(columns), len(args), qi.filename, nScan.Line,
+				getStrTruncated(qi.strQuery), constDisclaimerSQLScan)
+			failed = true
+			continue
+		}
+		var messages []string
+		for i, nArg := range args {
+			if message := checkScanDestinationType(columns[i], nArg, tr); message != "" {
+				messages = append(messages, fmt.Sprintf("%s:%d:%d: %s", qi.filename, nArg.Line, nArg.Column, message))
+			}
+		}
+		if len(messages) > 0 {
+			notPass(constCheckIDSQLScan, pos, "SQL query in %s returns columns whose types do not match the destinations of Scan() in %s:%d: %s\n%s\n%s",
+				getQueryLocation(qi, stmts[0].Pos()), qi.filename, nScan.Line,
+				getStrTruncated(qi.strQuery), strings.Join(messages, "\n"), constDisclaimerSQLScan)
+			failed = true
+		}
+	}
+	return
+}
+
+// checkScanDestinationType returns a message if a column cannot be scanned into a destination, e.g. a
+// VARCHAR into an *int, or "" if it can or if this is unknown.
+func checkScanDestinationType(column sqlparser.ResultColumn, nArg *fileparser.Node, tr *typeResolver) string {
+	var name = column.Type
+	if index := strings.IndexAny(name, "( "); index != -1 {
+		name = name[:index] // e.g. "VARCHAR(255)" or "TIMESTAMP WITH TIME ZONE"
+	}
+	var category, known = sqlTypeCategories[name]
+	if !known {
+		return ""
+	}
+	var t, ok = tr.exprType(nArg)
+	if !ok || !t.pointer {
+		return ""
+	}
+	var goName = t.name
+	if t.pkgPath != "" {
+		goName = t.pkgPath + "." + t.name
+	}
+	var categories, restricted = goScanCategories[goName]
+	if !restricted {
+		return ""
+	}
+	for _, c := range categories {
+		if c == category {
+			return ""
+		}
+	}
+	var columnName = column.Name
+	if columnName == "" {
+		columnName = "?"
+	}
+	return fmt.Sprintf("column %s of type %s cannot be scanned into %s of type *%s", columnName, column.Type, nArg.Bytes, goName)
+}
+
+//------------------------------------------------------------------------------
+
+// findScanCalls returns the calls to Scan() on the result of the call nCall, e.g. "db.QueryRow(q).Scan(&a)",
+// or "rows.Scan(&a)" after "rows, err := db.Query(q)" in the same function (if rows is not assigned again).
+func findScanCalls(nCall *fileparser.Node) (nScans []*fileparser.Node) {
+	var nFather = nCall.Father
+	if nFather == nil {
+		return
+	}
+	if nFather.TypeStr == "SelectorExpr" && nFather.Children[0] == nCall && nFather.Children[1].Name == "Scan" &&
+		nFather.Father != nil && nFather.Father.TypeStr == "CallExpr" && nFather.Father.Children[0] == nFather {
+		return []*fileparser.Node{nFather.Father} // db.QueryRow(q).Scan(...)
+	}
+
+	var resultName string // e.g. "rows"
+	var declNode *fileparser.Node
+	switch nFather.TypeStr {
+	case "AssignStmt": // rows, err := db.Query(q)
+		var lhs, rhs = nFather.AssignStmtLhs(), nFather.AssignStmtRhs()
+		if len(rhs) != 1 || rhs[0] != nCall || len(lhs) == 0 || lhs[0].TypeStr != "Ident" {
+			return
+		}
+		resultName = lhs[0].Name
+		if nFather.Operator() == ":=" {
+			declNode = nFather
+		} else {
+			declNode = lhs[0].FindLocalDeclaration(resultName)
+		}
+	case "ValueSpec": // var rows, err = db.Query(q)
+		var values = nFather.ValueSpecValues()
+		if len(values) != 1 || values[0] != nCall || len(nFather.DeclaredNames()) == 0 {
+			return
+		}
+		resultName = nFather.DeclaredNames()[0]
+		declNode = nFather
+	}
+	if declNode == nil || resultName == "_" {
+		return
+	}
+
+	var nFunc = nFather
+	for nFunc != nil && nFunc.TypeStr != "FuncDecl" && nFunc.TypeStr != "FuncLit" {
+		nFunc = nFunc.Father
+	}
+	if nFunc == nil {
+		return
+	}
+	var reassigned = false
+	nFunc.Visit(func(n *fileparser.Node) {
+		switch {
+		case n.TypeStr == "AssignStmt" && n != nFather:
+			for _, nLhs := range n.AssignStmtLhs() {
+				if nLhs.TypeStr == "Ident" && nLhs.Name == resultName && n.Operator() != ":=" && nLhs.FindLocalDeclaration(nLhs.Name) == declNode {
+					reassigned = true
+				}
+			}
+		case n.TypeStr == "CallExpr" && n.BytesIndexBegin > nCall.BytesIndexEnd && len(n.Children) > 0 && n.Children[0].TypeStr == "SelectorExpr":
+			var nX = n.Children[0].Children[0]
+			if n.Children[0].Children[1].Name == "Scan" && nX.TypeStr == "Ident" && nX.Name == resultName &&
+				nX.FindLocalDeclaration(nX.Name) == declNode {
+				nScans = append(nScans, n)
+			}
+		}
+	})
+	if reassigned {
+		return nil
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,97 @@
+package src
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/sqlparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+// LoadSQLSchema builds the schema of the database from the CREATE TABLE/VIEW, ALTER TABLE and DROP
+// statements of a directory of migration files (*.sql, in alphabetical order, except *.down.sql)
+// or of a single file (e.g. a dump).
+func LoadSQLSchema(path string, dialect *sqlparser.Dialect) (*sqlparser.Schema, error) {
+
+	var fileInfo, err = os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var files = []string{path}
+	if fileInfo.IsDir() {
+		files = nil
+		var fileInfos, err = ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range fileInfos {
+			if !fi.IsDir() && strings.HasSuffix(fi.Name(), ".sql") && !strings.HasSuffix(fi.Name(), ".down.sql") {
+				files = append(files, filepath.Join(path, fi.Name()))
+			}
+		}
+		sort.Strings(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .sql file in directory %s", path)
+		}
+	}
+
+	var schema = sqlparser.NewSchema()
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var text = stripSQLMigrationNoise(string(content))
+		statements, err := sqlparser.SplitStatements(text, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err.Error())
+		}
+		for _, statement := range statements {
+			stmts, err := sqlparser.Parse(statement.Text, dialect)
+			if err != nil {
+				if util.IsWarn() {
+					var line = strings.Count(text[:statement.Offset], "\n") + 1
+					util.Warn("File '%s:%d': Cannot parse this statement of the schema: %s", file, line, err.Error())
+				}
+				continue
+			}
+			for _, stmt := range stmts {
+				schema.Apply(stmt)
+			}
+		}
+	}
+	if util.IsInfo() {
+		util.Info("Loaded %d tables and views from %s", schema.TableCount(), path)
+	}
+	return schema, nil
+}
+
+// stripSQLMigrationNoise removes what is not SQL from a migration file or a dump: the "down" part of
+// a goose migration, and the data of the COPY statements of pg_dump (lines are kept blank, so that
+// line numbers remain the same).
+func stripSQLMigrationNoise(text string) string {
+	var lines = strings.Split(text, "\n")
+	var inCopyData = false
+	for i, line := range lines {
+		var trimmed = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Down") || strings.HasPrefix(trimmed, "-- +migrate Down"):
+			return strings.Join(lines[:i], "\n")
+		case inCopyData:
+			inCopyData = (trimmed != `\.`)
+			lines[i] = ""
+		case strings.HasPrefix(strings.ToUpper(trimmed), "COPY ") && strings.HasSuffix(strings.ToUpper(trimmed), "FROM STDIN;"):
+			inCopyData = true
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//------------------------------------------------------------------------------
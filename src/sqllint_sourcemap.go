@@ -0,0 +1,82 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+)
+
+//------------------------------------------------------------------------------
+
+// sourceSegment is a part of a query which is copied from a string literal of the Go code, or from
+// a file embedded with //go:embed.
+type sourceSegment struct {
+	offset    int // in the query
+	length    int
+	filename  string
+	nLiteral  *fileparser.Node // BasicLit, nil for an embedded file
+	fileText  string           // content of the embedded file
+	litOffset int              // in the value of nLiteral, or in fileText
+}
+
+// sourceMap maps the offsets of a query to the string literals its parts come from, sorted by offset.
+// The parts which do not come from a literal (e.g. the values of "%d" or the unknown parts) are not mapped.
+type sourceMap []sourceSegment
+
+//------------------------------------------------------------------------------
+
+// shifted returns the source map of a string which is prefixed with delta characters.
+func (sm sourceMap) shifted(delta int) sourceMap {
+	var result = make(sourceMap, len(sm))
+	for i, segment := range sm {
+		segment.offset += delta
+		result[i] = segment
+	}
+	return result
+}
+
+// slice returns the source map of the substring [begin:end].
+func (sm sourceMap) slice(begin int, end int) (result sourceMap) {
+	for _, segment := range sm {
+		var segBegin, segEnd = segment.offset, segment.offset + segment.length
+		if segBegin < begin {
+			segBegin = begin
+		}
+		if segEnd > end {
+			segEnd = end
+		}
+		if segBegin >= segEnd {
+			continue
+		}
+		segment.litOffset += segBegin - segment.offset
+		segment.offset = segBegin - begin
+		segment.length = segEnd - segBegin
+		result = append(result, segment)
+	}
+	return
+}
+
+// position returns "file:line:column" of the character at this offset in the query, or ok=false
+// if this character does not come from a string literal. An offset just after the last character
+// of a literal (e.g. an error at the end of the query) is mapped to the end of this literal.
+func (sm sourceMap) position(offset int) (location string, ok bool) {
+	for i, segment := range sm {
+		var isEnd = (offset == segment.offset+segment.length) &&
+			(i == len(sm)-1 || sm[i+1].offset != offset)
+		if offset >= segment.offset && (offset < segment.offset+segment.length || isEnd) {
+			var litOffset = segment.litOffset + offset - segment.offset
+			if segment.nLiteral == nil {
+				var line = strings.Count(segment.fileText[:litOffset], "\n") + 1
+				var column = litOffset - (strings.LastIndex(segment.fileText[:litOffset], "\n") + 1) + 1
+				return fmt.Sprintf("%s:%d:%d", segment.filename, line, column), true
+			}
+			if line, column, ok := segment.nLiteral.StringLiteralPosition(litOffset); ok {
+				return fmt.Sprintf("%s:%d:%d", segment.filename, line, column), true
+			}
+		}
+	}
+	return "", false
+}
+
+//------------------------------------------------------------------------------
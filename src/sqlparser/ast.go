@@ -0,0 +1,347 @@
+package sqlparser
+
+//------------------------------------------------------------------------------
+// statements
+
+// Statement is a SQL statement: *SelectStmt, *InsertStmt, *UpdateStmt, *DeleteStmt, *CreateTableStmt,
+// *CreateViewStmt, *AlterTableStmt, *DropStmt, *TruncateStmt or *OtherStmt.
+type Statement interface {
+	Pos() int // offset of the first character of the statement in the query
+}
+
+// SelectStmt is a SELECT, possibly with UNION/INTERSECT/EXCEPT.
+type SelectStmt struct {
+	Offset      int
+	With        []*CommonTableExpr
+	Distinct    bool
+	Columns     []*SelectColumn
+	From        []*TableRef
+	Where       Expr
+	GroupBy     []Expr
+	Having      Expr
+	Compound    *CompoundSelect // UNION ... (nil if none)
+	OrderBy     []*OrderByItem
+	Limit       Expr
+	LimitOffset Expr // OFFSET
+}
+
+// CompoundSelect is the right-hand side of a UNION, INTERSECT or EXCEPT.
+type CompoundSelect struct {
+	Operator string // "UNION", "UNION ALL", "INTERSECT", "EXCEPT" ...
+	Select   *SelectStmt
+}
+
+// CommonTableExpr is an element of a WITH clause.
+type CommonTableExpr struct {
+	Name    string
+	Columns []string
+	Stmt    Statement
+}
+
+// SelectColumn is an element of the list of columns of a SELECT or a RETURNING clause.
+type SelectColumn struct {
+	Offset    int
+	Star      bool   // "*" or "foo.*"
+	StarTable string // "foo" in "foo.*"
+	Expr      Expr   // nil if Star
+	Alias     string
+}
+
+// TableRef is a table of a FROM clause, a JOIN, or the table of an INSERT/UPDATE/DELETE.
+type TableRef struct {
+	Offset   int
+	Schema   string // "foo" in "foo.bar"
+	Name     string // "" for a subquery, a table function or a parenthesized join
+	Alias    string
+	Subquery *SelectStmt
+	Function *FuncCall   // e.g. generate_series(1, 10)
+	Nested   []*TableRef // (a JOIN b)
+	Join     string      // "", ",", "JOIN", "LEFT JOIN" ...
+	On       Expr
+	Using    []string
+}
+
+// OrderByItem is an element of an ORDER BY clause.
+type OrderByItem struct {
+	Expr Expr
+	Desc bool
+}
+
+// Assignment is "column = value" in an UPDATE or an upsert.
+type Assignment struct {
+	Column *ColumnRef
+	Value  Expr
+}
+
+// InsertStmt is an INSERT or a REPLACE.
+type InsertStmt struct {
+	Offset        int
+	With          []*CommonTableExpr
+	Table         *TableRef
+	Columns       []*ColumnRef
+	Rows          [][]Expr    // VALUES (...), (...)
+	Select        *SelectStmt // INSERT ... SELECT
+	Set           []*Assignment
+	OnConflict    []*Assignment // ON DUPLICATE KEY UPDATE / ON CONFLICT DO UPDATE SET
+	Returning     []*SelectColumn
+	DefaultValues bool
+}
+
+// UpdateStmt is an UPDATE.
+type UpdateStmt struct {
+	Offset    int
+	With      []*CommonTableExpr
+	Tables    []*TableRef // the updated table first, then the joined tables (mysql)
+	Set       []*Assignment
+	From      []*TableRef // UPDATE ... FROM (postgres, sqlite)
+	Where     Expr
+	OrderBy   []*OrderByItem
+	Limit     Expr
+	Returning []*SelectColumn
+}
+
+// DeleteStmt is a DELETE.
+type DeleteStmt struct {
+	Offset    int
+	With      []*CommonTableExpr
+	Targets   []string    // DELETE a, b FROM ... (mysql)
+	Tables    []*TableRef // FROM ...
+	Using     []*TableRef // USING ... (postgres)
+	Where     Expr
+	OrderBy   []*OrderByItem
+	Limit     Expr
+	Returning []*SelectColumn
+}
+
+// CreateTableStmt is a CREATE TABLE.
+type CreateTableStmt struct {
+	Offset      int
+	Table       *TableRef
+	IfNotExists bool
+	Columns     []*ColumnDef
+	AsSelect    *SelectStmt // CREATE TABLE ... AS SELECT
+}
+
+// ColumnDef is a column of a CREATE TABLE or an ALTER TABLE ... ADD COLUMN.
+type ColumnDef struct {
+	Offset int
+	Name   string
+	Type   string // e.g. "VARCHAR(255)", upper case
+}
+
+// CreateViewStmt is a CREATE VIEW.
+type CreateViewStmt struct {
+	Offset  int
+	View    *TableRef
+	Columns []string
+	Select  *SelectStmt
+}
+
+// AlterTableStmt is an ALTER TABLE.
+type AlterTableStmt struct {
+	Offset        int
+	Table         *TableRef
+	AddColumns    []*ColumnDef
+	DropColumns   []string
+	RenameColumns map[string]string // old name => new name
+	RenameTo      string
+}
+
+// DropStmt is a DROP TABLE/VIEW/INDEX/...
+type DropStmt struct {
+	Offset int
+	Kind   string // "TABLE", "VIEW" ...
+	Names  []string
+}
+
+// TruncateStmt is a TRUNCATE.
+type TruncateStmt struct {
+	Offset int
+	Tables []string
+}
+
+// OtherStmt is a statement which is not analyzed (BEGIN, SET, SHOW, CREATE INDEX ...).
+type OtherStmt struct {
+	Offset  int
+	Keyword string // e.g. "BEGIN"
+}
+
+func (s *SelectStmt) Pos() int      { return s.Offset }
+func (s *InsertStmt) Pos() int      { return s.Offset }
+func (s *UpdateStmt) Pos() int      { return s.Offset }
+func (s *DeleteStmt) Pos() int      { return s.Offset }
+func (s *CreateTableStmt) Pos() int { return s.Offset }
+func (s *CreateViewStmt) Pos() int  { return s.Offset }
+func (s *AlterTableStmt) Pos() int  { return s.Offset }
+func (s *DropStmt) Pos() int        { return s.Offset }
+func (s *TruncateStmt) Pos() int    { return s.Offset }
+func (s *OtherStmt) Pos() int       { return s.Offset }
+
+// StatementKind returns the kind of a statement, e.g. "SELECT", "DROP TABLE", "TRUNCATE" or "BEGIN".
+func StatementKind(stmt Statement) string {
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		return "SELECT"
+	case *InsertStmt:
+		return "INSERT"
+	case *UpdateStmt:
+		return "UPDATE"
+	case *DeleteStmt:
+		return "DELETE"
+	case *CreateTableStmt:
+		return "CREATE TABLE"
+	case *CreateViewStmt:
+		return "CREATE VIEW"
+	case *AlterTableStmt:
+		return "ALTER TABLE"
+	case *DropStmt:
+		return "DROP " + s.Kind
+	case *TruncateStmt:
+		return "TRUNCATE"
+	case *OtherStmt:
+		return s.Keyword
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+// expressions
+
+// Expr is an expression: *ColumnRef, *Literal, *Placeholder, *Variable, *BinaryExpr, *UnaryExpr, *FuncCall,
+// *SubqueryExpr, *InExpr, *BetweenExpr, *IsExpr, *CaseExpr, *CastExpr, *ListExpr or *IntervalExpr.
+type Expr interface {
+	Pos() int // offset of the first character of the expression in the query
+}
+
+// ColumnRef is a column name, e.g. "foo", "t.foo" or "s.t.foo".
+type ColumnRef struct {
+	Offset int
+	Schema string
+	Table  string
+	Name   string
+}
+
+// Literal is a constant value.
+type Literal struct {
+	Offset int
+	Kind   string // "string", "number", "null", "bool", "default" (DEFAULT in VALUES), "keyword" (CURRENT_TIMESTAMP ...)
+	Value  string
+}
+
+// Placeholder is a bind parameter, e.g. "?", "$1" or ":name".
+type Placeholder struct {
+	Offset int
+	Text   string
+}
+
+// Variable is a mysql variable, e.g. "@foo" or "@@session.sql_mode".
+type Variable struct {
+	Offset int
+	Name   string
+}
+
+// BinaryExpr is e.g. "a + b", "a = b", "a AND b" or "a LIKE b".
+type BinaryExpr struct {
+	Operator string // upper case
+	Left     Expr
+	Right    Expr
+}
+
+// UnaryExpr is e.g. "-a" or "NOT a".
+type UnaryExpr struct {
+	Offset   int
+	Operator string // upper case
+	X        Expr
+}
+
+// FuncCall is a function call, e.g. "COUNT(*)".
+type FuncCall struct {
+	Offset   int
+	Name     string // upper case
+	Args     []Expr
+	Star     bool // COUNT(*)
+	Distinct bool // COUNT(DISTINCT a)
+}
+
+// SubqueryExpr is "(SELECT ...)" or "EXISTS (SELECT ...)".
+type SubqueryExpr struct {
+	Offset int
+	Exists bool
+	Select *SelectStmt
+}
+
+// InExpr is "a [NOT] IN (b, c)" or "a [NOT] IN (SELECT ...)".
+type InExpr struct {
+	X      Expr
+	Not    bool
+	List   []Expr
+	Select *SelectStmt
+}
+
+// BetweenExpr is "a [NOT] BETWEEN b AND c".
+type BetweenExpr struct {
+	X    Expr
+	Not  bool
+	Low  Expr
+	High Expr
+}
+
+// IsExpr is "a IS [NOT] NULL/TRUE/FALSE/UNKNOWN" or "a IS [NOT] DISTINCT FROM b".
+type IsExpr struct {
+	X     Expr
+	Not   bool
+	Value string // "NULL", "TRUE", "FALSE", "UNKNOWN" or "DISTINCT FROM"
+	Other Expr   // for "DISTINCT FROM"
+}
+
+// CaseExpr is "CASE [operand] WHEN ... THEN ... [ELSE ...] END".
+type CaseExpr struct {
+	Offset  int
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is a "WHEN ... THEN ..." of a CASE.
+type When struct {
+	Cond   Expr
+	Result Expr
+}
+
+// CastExpr is "CAST(a AS type)" or "a::type".
+type CastExpr struct {
+	Offset int
+	X      Expr
+	Type   string
+}
+
+// ListExpr is a parenthesized list, e.g. "(a, b)", or an array, e.g. "ARRAY[a, b]".
+type ListExpr struct {
+	Offset int
+	Items  []Expr
+}
+
+// IntervalExpr is "INTERVAL 1 DAY" or "INTERVAL '1 day'".
+type IntervalExpr struct {
+	Offset int
+	Value  Expr
+	Unit   string
+}
+
+func (e *ColumnRef) Pos() int    { return e.Offset }
+func (e *Literal) Pos() int      { return e.Offset }
+func (e *Placeholder) Pos() int  { return e.Offset }
+func (e *Variable) Pos() int     { return e.Offset }
+func (e *BinaryExpr) Pos() int   { return e.Left.Pos() }
+func (e *UnaryExpr) Pos() int    { return e.Offset }
+func (e *FuncCall) Pos() int     { return e.Offset }
+func (e *SubqueryExpr) Pos() int { return e.Offset }
+func (e *InExpr) Pos() int       { return e.X.Pos() }
+func (e *BetweenExpr) Pos() int  { return e.X.Pos() }
+func (e *IsExpr) Pos() int       { return e.X.Pos() }
+func (e *CaseExpr) Pos() int     { return e.Offset }
+func (e *CastExpr) Pos() int     { return e.Offset }
+func (e *ListExpr) Pos() int     { return e.Offset }
+func (e *IntervalExpr) Pos() int { return e.Offset }
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,472 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// words parsed as column names which are rather keywords, e.g. "SECOND" in "TIMESTAMPDIFF(SECOND, a, b)"
+// or "CHAR" in "CONVERT(a, CHAR)", or implicit columns
+var nonColumnWords = makeWordSet(
+	"CHAR", "NCHAR", "SIGNED", "UNSIGNED", "DATE", "DATETIME", "TIME", "DECIMAL", "INTEGER", "BINARY", "JSON", "DOUBLE",
+	"FLOAT", "REAL", "ROWID", "OID", "_ROWID_", "CTID", "XMIN", "XMAX",
+)
+
+//------------------------------------------------------------------------------
+
+// checker validates the tables and the columns used by a statement against a schema.
+type checker struct {
+	schema *Schema
+	errors []*Error
+}
+
+// source is a table which may be referred to in a scope, e.g. "t" in "SELECT t.a FROM foo t".
+type source struct {
+	name    string   // alias, or table name
+	table   string   // table name, for messages
+	columns []string // nil if unknown
+}
+
+// scope is what may be referred to by the names of an expression.
+type scope struct {
+	sources []*source
+	aliases []string // aliases of the columns of a SELECT
+	ctes    map[string]*source
+	outer   *scope // for correlated subqueries
+}
+
+func newScope(outer *scope) *scope {
+	var sc = &scope{outer: outer, ctes: make(map[string]*source)}
+	if outer != nil {
+		for name, cte := range outer.ctes {
+			sc.ctes[name] = cte
+		}
+	}
+	return sc
+}
+
+func (sc *scope) find(name string) *source {
+	for _, src := range sc.sources {
+		if strings.EqualFold(src.name, name) {
+			return src
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Check returns the tables, columns and aliases used by a statement which do not exist in the schema,
+// and the INSERT with a number of values different from the number of columns.
+func (s *Schema) Check(stmt Statement) []*Error {
+	var c = &checker{schema: s}
+	c.checkStatement(stmt, newScope(nil))
+	return c.errors
+}
+
+func (c *checker) errorf(offset int, message string, args ...interface{}) {
+	c.errors = append(c.errors, &Error{Offset: offset, Message: fmt.Sprintf(message, args...)})
+}
+
+func (c *checker) checkStatement(stmt Statement, sc *scope) {
+	switch stmt := stmt.(type) {
+	case *SelectStmt:
+		c.checkSelect(stmt, sc)
+	case *InsertStmt:
+		c.checkInsert(stmt, sc)
+	case *UpdateStmt:
+		c.checkUpdate(stmt, sc)
+	case *DeleteStmt:
+		c.checkDelete(stmt, sc)
+	case *TruncateStmt:
+		for _, name := range stmt.Tables {
+			if c.schema.Table(name) == nil {
+				c.errorf(stmt.Offset, "table %s does not exist", name)
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// checkWith adds the common table expressions to the scope.
+func (c *checker) checkWith(ctes []*CommonTableExpr, sc *scope) {
+	for _, cte := range ctes {
+		var src = &source{name: cte.Name, table: cte.Name, columns: cte.Columns}
+		sc.ctes[strings.ToLower(cte.Name)] = src // before checking it, for WITH RECURSIVE
+		if selectStmt, ok := cte.Stmt.(*SelectStmt); ok {
+			var names, _ = c.checkSelect(selectStmt, sc)
+			if src.columns == nil {
+				src.columns = names
+			}
+		} else {
+			c.checkStatement(cte.Stmt, sc)
+		}
+	}
+}
+
+// checkSelect checks a SELECT and returns the names of its columns (nil if unknown) and their number
+// (-1 if unknown).
+func (c *checker) checkSelect(stmt *SelectStmt, outer *scope) (names []string, count int) {
+
+	var withScope = newScope(outer)
+	c.checkWith(stmt.With, withScope)
+
+	var firstScope *scope
+	count = -1
+	for core := stmt; core != nil; {
+		var sc = newScope(withScope)
+		sc.ctes = withScope.ctes
+		c.addTableRefs(core.From, sc, withScope)
+		for _, column := range core.Columns {
+			if column.Star && column.StarTable != "" && sc.find(column.StarTable) == nil {
+				c.errorf(column.Offset, "unknown table or alias %s", column.StarTable)
+			}
+			if column.Expr != nil {
+				c.checkExpr(column.Expr, sc, false)
+			}
+			if column.Alias != "" {
+				sc.aliases = append(sc.aliases, column.Alias)
+			}
+		}
+		c.checkExpr(core.Where, sc, false)
+		for _, e := range core.GroupBy {
+			c.checkExpr(e, sc, true)
+		}
+		c.checkExpr(core.Having, sc, true)
+
+		if firstScope == nil {
+			firstScope = sc
+			names, count = selectOutputColumns(core, sc)
+		}
+		if core.Compound == nil {
+			break
+		}
+		core = core.Compound.Select
+	}
+
+	for _, item := range stmt.OrderBy {
+		c.checkExpr(item.Expr, firstScope, true)
+	}
+	c.checkExpr(stmt.Limit, firstScope, false)
+	c.checkExpr(stmt.LimitOffset, firstScope, false)
+	return
+}
+
+// selectOutputColumns returns the names of the columns of a SELECT (nil if unknown) and their number
+// (-1 if unknown).
+func selectOutputColumns(core *SelectStmt, sc *scope) (names []string, count int) {
+	var namesKnown = true
+	for _, column := range core.Columns {
+		switch {
+		case column.Star:
+			for _, src := range sc.sources {
+				if column.StarTable != "" && !strings.EqualFold(src.name, column.StarTable) {
+					continue
+				}
+				if src.columns == nil {
+					return nil, -1
+				}
+				names = append(names, src.columns...)
+			}
+		case column.Alias != "":
+			names = append(names, column.Alias)
+		default:
+			if columnRef, ok := column.Expr.(*ColumnRef); ok {
+				names = append(names, columnRef.Name)
+			} else {
+				names = append(names, "")
+				namesKnown = false // named by the database, e.g. "count" or "COUNT(*)"
+			}
+		}
+	}
+	count = len(names)
+	if !namesKnown {
+		names = nil
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// addTableRefs adds the tables of a FROM clause to the scope, and checks the ON conditions.
+func (c *checker) addTableRefs(refs []*TableRef, sc *scope, outer *scope) {
+	for _, ref := range refs {
+		switch {
+		case ref.Nested != nil:
+			c.addTableRefs(ref.Nested, sc, outer)
+			continue
+		case ref.Subquery != nil:
+			var names, _ = c.checkSelect(ref.Subquery, outer)
+			sc.sources = append(sc.sources, &source{name: ref.Alias, columns: names})
+		case ref.Function != nil:
+			c.checkExpr(ref.Function, sc, false)
+			sc.sources = append(sc.sources, &source{name: ref.Alias})
+		default:
+			var src = c.tableSource(ref, sc)
+			if src != nil {
+				sc.sources = append(sc.sources, src)
+			}
+		}
+	}
+	for _, ref := range refs {
+		c.checkExpr(ref.On, sc, false)
+		for _, name := range ref.Using {
+			if !c.isColumnInScope(name, sc) {
+				c.errorf(ref.Offset, "column %s does not exist%s", name, describeSources(sc))
+			}
+		}
+	}
+}
+
+// tableSource returns the source for a table (or a common table expression), or nil for DUAL.
+func (c *checker) tableSource(ref *TableRef, sc *scope) *source {
+	var name = ref.Alias
+	if name == "" {
+		name = ref.Name
+	}
+	if cte, ok := sc.ctes[strings.ToLower(ref.Name)]; ok && ref.Schema == "" {
+		return &source{name: name, table: ref.Name, columns: cte.columns}
+	}
+	var table = c.schema.Table(ref.Name)
+	if table == nil {
+		if strings.EqualFold(ref.Name, "DUAL") {
+			return nil
+		}
+		c.errorf(ref.Offset, "table %s does not exist", ref.Name)
+		return &source{name: name, table: ref.Name} // unknown columns, to avoid more errors
+	}
+	return &source{name: name, table: table.Name, columns: table.Columns}
+}
+
+//------------------------------------------------------------------------------
+
+func (c *checker) checkInsert(stmt *InsertStmt, outer *scope) {
+
+	var sc = newScope(outer)
+	c.checkWith(stmt.With, sc)
+	var src = c.tableSource(stmt.Table, sc)
+	if src == nil {
+		return
+	}
+	sc.sources = append(sc.sources, src)
+
+	for _, column := range stmt.Columns {
+		c.checkColumnOfSource(column, src)
+	}
+
+	var expected = len(stmt.Columns)
+	if expected == 0 {
+		expected = len(src.columns)
+		if src.columns == nil {
+			expected = -1
+		}
+	}
+	for _, row := range stmt.Rows {
+		if expected != -1 && len(row) != expected {
+			var offset = stmt.Table.Offset
+			if len(row) > 0 {
+				offset = row[0].Pos()
+			}
+			c.errorf(offset, "INSERT has %d columns but %d values", expected, len(row))
+		}
+		for _, e := range row {
+			c.checkExpr(e, sc, false)
+		}
+	}
+	if stmt.Select != nil {
+		var _, count = c.checkSelect(stmt.Select, outer)
+		if expected != -1 && count != -1 && count != expected {
+			c.errorf(stmt.Select.Offset, "INSERT has %d columns but the SELECT returns %d", expected, count)
+		}
+	}
+	for _, assignment := range stmt.Set {
+		c.checkColumnOfSource(assignment.Column, src)
+		c.checkExpr(assignment.Value, sc, false)
+	}
+
+	if len(stmt.OnConflict) > 0 {
+		var conflictScope = newScope(sc)
+		conflictScope.sources = []*source{src, {name: "EXCLUDED", table: src.table, columns: src.columns}}
+		for _, assignment := range stmt.OnConflict {
+			c.checkColumnOfSource(assignment.Column, src)
+			c.checkExpr(assignment.Value, conflictScope, false)
+		}
+	}
+	c.checkReturning(stmt.Returning, sc)
+}
+
+func (c *checker) checkUpdate(stmt *UpdateStmt, outer *scope) {
+	var sc = newScope(outer)
+	c.checkWith(stmt.With, sc)
+	c.addTableRefs(append(append([]*TableRef{}, stmt.Tables...), stmt.From...), sc, outer)
+	for _, assignment := range stmt.Set {
+		c.checkExpr(assignment.Column, sc, false)
+		c.checkExpr(assignment.Value, sc, false)
+	}
+	c.checkExpr(stmt.Where, sc, false)
+	for _, item := range stmt.OrderBy {
+		c.checkExpr(item.Expr, sc, false)
+	}
+	c.checkExpr(stmt.Limit, sc, false)
+	c.checkReturning(stmt.Returning, sc)
+}
+
+func (c *checker) checkDelete(stmt *DeleteStmt, outer *scope) {
+	var sc = newScope(outer)
+	c.checkWith(stmt.With, sc)
+	c.addTableRefs(append(append([]*TableRef{}, stmt.Tables...), stmt.Using...), sc, outer)
+	for _, name := range stmt.Targets {
+		if sc.find(name) == nil {
+			c.errorf(stmt.Offset, "unknown table or alias %s", name)
+		}
+	}
+	c.checkExpr(stmt.Where, sc, false)
+	for _, item := range stmt.OrderBy {
+		c.checkExpr(item.Expr, sc, false)
+	}
+	c.checkExpr(stmt.Limit, sc, false)
+	c.checkReturning(stmt.Returning, sc)
+}
+
+func (c *checker) checkReturning(columns []*SelectColumn, sc *scope) {
+	for _, column := range columns {
+		if column.Expr != nil {
+			c.checkExpr(column.Expr, sc, false)
+		}
+	}
+}
+
+// checkColumnOfSource checks that the column of an INSERT exists in the table.
+func (c *checker) checkColumnOfSource(column *ColumnRef, src *source) {
+	if src.columns != nil && !containsFold(src.columns, column.Name) {
+		c.errorf(column.Offset, "column %s does not exist in table %s", column.Name, src.table)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// checkExpr checks the columns and the subqueries of an expression; allowAliases is true if the
+// aliases of the columns of the SELECT may be used (e.g. in ORDER BY).
+func (c *checker) checkExpr(e Expr, sc *scope, allowAliases bool) {
+	switch e := e.(type) {
+	case *ColumnRef:
+		c.checkColumnRef(e, sc, allowAliases)
+	case *BinaryExpr:
+		c.checkExpr(e.Left, sc, allowAliases)
+		c.checkExpr(e.Right, sc, allowAliases)
+	case *UnaryExpr:
+		c.checkExpr(e.X, sc, allowAliases)
+	case *FuncCall:
+		for _, arg := range e.Args {
+			c.checkExpr(arg, sc, allowAliases)
+		}
+	case *SubqueryExpr:
+		c.checkSelect(e.Select, sc)
+	case *InExpr:
+		c.checkExpr(e.X, sc, allowAliases)
+		for _, item := range e.List {
+			c.checkExpr(item, sc, allowAliases)
+		}
+		if e.Select != nil {
+			c.checkSelect(e.Select, sc)
+		}
+	case *BetweenExpr:
+		c.checkExpr(e.X, sc, allowAliases)
+		c.checkExpr(e.Low, sc, allowAliases)
+		c.checkExpr(e.High, sc, allowAliases)
+	case *IsExpr:
+		c.checkExpr(e.X, sc, allowAliases)
+		c.checkExpr(e.Other, sc, allowAliases)
+	case *CaseExpr:
+		c.checkExpr(e.Operand, sc, allowAliases)
+		for _, when := range e.Whens {
+			c.checkExpr(when.Cond, sc, allowAliases)
+			c.checkExpr(when.Result, sc, allowAliases)
+		}
+		c.checkExpr(e.Else, sc, allowAliases)
+	case *CastExpr:
+		c.checkExpr(e.X, sc, allowAliases)
+	case *ListExpr:
+		for _, item := range e.Items {
+			c.checkExpr(item, sc, allowAliases)
+		}
+	case *IntervalExpr:
+		c.checkExpr(e.Value, sc, allowAliases)
+	}
+}
+
+func (c *checker) checkColumnRef(column *ColumnRef, sc *scope, allowAliases bool) {
+
+	if column.Table != "" {
+		for s := sc; s != nil; s = s.outer {
+			if src := s.find(column.Table); src != nil {
+				if src.columns != nil && !containsFold(src.columns, column.Name) {
+					c.errorf(column.Offset, "column %s does not exist in %s", column.Name, describeSource(src))
+				}
+				return
+			}
+		}
+		c.errorf(column.Offset, "unknown table or alias %s", column.Table)
+		return
+	}
+
+	if nonColumnWords[strings.ToUpper(column.Name)] || intervalUnits[strings.ToUpper(column.Name)] {
+		return
+	}
+	if allowAliases && containsFold(sc.aliases, column.Name) {
+		return
+	}
+	if !c.isColumnInScope(column.Name, sc) {
+		c.errorf(column.Offset, "column %s does not exist%s", column.Name, describeSources(sc))
+	}
+}
+
+// isColumnInScope returns true if a source of the scope (or of an outer scope) has this column,
+// or has unknown columns.
+func (c *checker) isColumnInScope(name string, sc *scope) bool {
+	for s := sc; s != nil; s = s.outer {
+		for _, src := range s.sources {
+			if src.columns == nil || containsFold(src.columns, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+func describeSource(src *source) string {
+	if src.table == "" {
+		return src.name
+	}
+	if src.name != "" && !strings.EqualFold(src.name, src.table) {
+		return "table " + src.table + " (alias " + src.name + ")"
+	}
+	return "table " + src.table
+}
+
+func describeSources(sc *scope) string {
+	var descriptions []string
+	for _, src := range sc.sources {
+		descriptions = append(descriptions, describeSource(src))
+	}
+	if len(descriptions) == 0 {
+		return ""
+	}
+	return " in " + strings.Join(descriptions, ", ")
+}
+
+func containsFold(list []string, str string) bool {
+	for _, el := range list {
+		if strings.EqualFold(el, str) {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,98 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Dialect describes the syntax differences between the supported SQL databases.
+type Dialect struct {
+	Name string
+
+	backslashEscapes      bool            // '\'' is a quote in a string
+	doubleQuotedStrings   bool            // "foo" is a string, not an identifier
+	backquotedIdentifiers bool            // `foo`
+	bracketedIdentifiers  bool            // [foo]
+	dollarQuotedStrings   bool            // $$foo$$ or $tag$foo$tag$
+	escapeStrings         bool            // E'foo\n'
+	hashComments          bool            // # foo
+	doubleColonCast       bool            // foo::int
+	placeholders          map[string]bool // "?", "?N", "$N", ":name", "@name", "$name"
+	reservedWords         map[string]bool // in addition to commonReservedWords
+}
+
+// DialectNames are the names accepted by GetDialect.
+var DialectNames = []string{"mysql", "postgres", "sqlite"}
+
+var dialects = map[string]*Dialect{
+	"mysql": {
+		Name:                  "mysql",
+		backslashEscapes:      true,
+		doubleQuotedStrings:   true,
+		backquotedIdentifiers: true,
+		hashComments:          true,
+		placeholders:          map[string]bool{"?": true},
+		reservedWords:         makeWordSet("STRAIGHT_JOIN", "REGEXP", "RLIKE", "DIV", "MOD", "XOR", "KEY"),
+	},
+	"postgres": {
+		Name:                "postgres",
+		dollarQuotedStrings: true,
+		escapeStrings:       true,
+		doubleColonCast:     true,
+		placeholders:        map[string]bool{"$N": true},
+		reservedWords:       makeWordSet("ILIKE", "SIMILAR", "RETURNING", "CONFLICT", "LATERAL", "FETCH"),
+	},
+	"sqlite": {
+		Name:                  "sqlite",
+		backquotedIdentifiers: true,
+		bracketedIdentifiers:  true,
+		placeholders:          map[string]bool{"?": true, "?N": true, ":name": true, "@name": true, "$name": true},
+		reservedWords:         makeWordSet("GLOB", "RETURNING", "CONFLICT"),
+	},
+}
+
+//------------------------------------------------------------------------------
+
+// GetDialect returns the dialect with this name (see DialectNames).
+func GetDialect(name string) (*Dialect, error) {
+	var dialect, ok = dialects[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL dialect %s (known dialects: %s)", name, strings.Join(DialectNames, ", "))
+	}
+	return dialect, nil
+}
+
+// Placeholder returns the usual placeholder of the dialect: "?", or "$1" for postgres.
+func (d *Dialect) Placeholder() string {
+	if d.placeholders["?"] {
+		return "?"
+	}
+	return "$1"
+}
+
+//------------------------------------------------------------------------------
+
+// words which cannot be used as a column name or an alias without quotes
+var commonReservedWords = makeWordSet(
+	"ALL", "AND", "AS", "ASC", "BETWEEN", "BY", "CASE", "CREATE", "CROSS", "DELETE", "DESC", "DISTINCT", "DROP",
+	"ELSE", "END", "EXCEPT", "EXISTS", "FOR", "FROM", "FULL", "GROUP", "HAVING", "IN", "INNER", "INSERT",
+	"INTERSECT", "INTO", "IS", "JOIN", "LEFT", "LIKE", "LIMIT", "NATURAL", "NOT", "NULL", "OFFSET", "ON", "OR",
+	"ORDER", "OUTER", "RIGHT", "SELECT", "SET", "TABLE", "THEN", "UNION", "UPDATE", "USING", "VALUES", "WHEN",
+	"WHERE", "WITH",
+)
+
+func (d *Dialect) isReserved(upperWord string) bool {
+	return commonReservedWords[upperWord] || d.reservedWords[upperWord]
+}
+
+func makeWordSet(words ...string) map[string]bool {
+	var m = make(map[string]bool, len(words))
+	for _, word := range words {
+		m[word] = true
+	}
+	return m
+}
+
+//------------------------------------------------------------------------------
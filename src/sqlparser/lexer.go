@@ -0,0 +1,231 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tokEOF         tokenKind = iota
+	tokWord                  // SELECT, foo
+	tokQuotedIdent           // `foo`, "foo", [foo]
+	tokString                // 'foo'
+	tokNumber                // 42, 1.5e3, 0x1F
+	tokPlaceholder           // ?, $1, :name
+	tokOperator              // = <> ( ) , ; ...
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // raw text, e.g. "'foo'"
+	value  string // unquoted value for strings and quoted identifiers, upper case for words
+	offset int    // in bytes in the query
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return "\"" + t.text + "\""
+}
+
+// operators, longest first
+var operators = []string{
+	"<=>", "->>", "!~*", "::", "<>", "!=", "<=", ">=", "||", "&&", "<<", ">>", "->", "~*", "!~", ":=",
+	"(", ")", ",", ";", ".", "=", "<", ">", "+", "-", "*", "/", "%", "&", "|", "^", "~", "!", "[", "]",
+}
+
+//------------------------------------------------------------------------------
+
+// tokenize splits a query into tokens, without comments and spaces.
+func tokenize(query string, dialect *Dialect) ([]token, error) {
+
+	var tokens []token
+	var i = 0
+	for i < len(query) {
+		var c = query[i]
+		var start = i
+
+		switch {
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+			continue
+
+		case strings.HasPrefix(query[i:], "--") || (c == '#' && dialect.hashComments):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+
+		case strings.HasPrefix(query[i:], "/*"):
+			var end = strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				return nil, &Error{Offset: start, Message: "unterminated comment"}
+			}
+			i += 2 + end + 2
+			continue
+
+		case c == '\'' || (c == '"' && dialect.doubleQuotedStrings) ||
+			((c == 'E' || c == 'e') && i+1 < len(query) && query[i+1] == '\'' && dialect.escapeStrings):
+			var backslashEscapes = dialect.backslashEscapes
+			if c == 'E' || c == 'e' { // E'foo\n' (postgres)
+				backslashEscapes = true
+				i++
+			}
+			var value, end, ok = readQuoted(query, i, query[i], backslashEscapes)
+			if !ok {
+				return nil, &Error{Offset: start, Message: "unterminated string"}
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokString, text: query[start:i], value: value, offset: start})
+
+		case c == '"' || (c == '`' && dialect.backquotedIdentifiers):
+			var value, end, ok = readQuoted(query, i, c, false)
+			if !ok {
+				return nil, &Error{Offset: start, Message: "unterminated quoted identifier"}
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokQuotedIdent, text: query[start:i], value: value, offset: start})
+
+		case c == '[' && dialect.bracketedIdentifiers:
+			var end = strings.IndexByte(query[i:], ']')
+			if end == -1 {
+				return nil, &Error{Offset: start, Message: "unterminated quoted identifier"}
+			}
+			i += end + 1
+			tokens = append(tokens, token{kind: tokQuotedIdent, text: query[start:i], value: query[start+1 : i-1], offset: start})
+
+		case c == '$' && dialect.dollarQuotedStrings && isDollarQuoteStart(query[i:]):
+			var tagEnd = strings.IndexByte(query[i+1:], '$') + i + 2
+			var tag = query[i:tagEnd]
+			var end = strings.Index(query[tagEnd:], tag)
+			if end == -1 {
+				return nil, &Error{Offset: start, Message: "unterminated dollar-quoted string"}
+			}
+			i = tagEnd + end + len(tag)
+			tokens = append(tokens, token{kind: tokString, text: query[start:i], value: query[tagEnd : tagEnd+end], offset: start})
+
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			i = readNumber(query, i)
+			if i < len(query) && isWordChar(query[i]) {
+				return nil, &Error{Offset: start, Message: fmt.Sprintf("invalid number %q", query[start:i+1])}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: query[start:i], value: query[start:i], offset: start})
+
+		case isWordStart(c):
+			for i < len(query) && isWordChar(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: query[start:i], value: strings.ToUpper(query[start:i]), offset: start})
+
+		case c == '?' || ((c == '$' || c == ':' || c == '@') && i+1 < len(query) && isWordChar(query[i+1]) &&
+			!(c == ':' && i > 0 && query[i-1] == ':')) || strings.HasPrefix(query[i:], "@@"):
+			i++
+			if query[i-1] == '@' && i < len(query) && query[i] == '@' { // @@global_variable (mysql)
+				i++
+			}
+			for i < len(query) && isWordChar(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokPlaceholder, text: query[start:i], value: query[start:i], offset: start})
+
+		default:
+			var found = false
+			for _, operator := range operators {
+				if strings.HasPrefix(query[i:], operator) {
+					i += len(operator)
+					tokens = append(tokens, token{kind: tokOperator, text: operator, value: operator, offset: start})
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, &Error{Offset: start, Message: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, offset: len(query)})
+	return tokens, nil
+}
+
+//------------------------------------------------------------------------------
+
+// readQuoted reads a string or an identifier beginning with the quote at query[begin], where a doubled
+// quote is an escaped quote.
+func readQuoted(query string, begin int, quote byte, backslashEscapes bool) (value string, end int, ok bool) {
+	var sb strings.Builder
+	for i := begin + 1; i < len(query); i++ {
+		if backslashEscapes && query[i] == '\\' && i+1 < len(query) {
+			sb.WriteByte(query[i+1])
+			i++
+		} else if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				sb.WriteByte(quote)
+				i++
+			} else {
+				return sb.String(), i + 1, true
+			}
+		} else {
+			sb.WriteByte(query[i])
+		}
+	}
+	return "", len(query), false
+}
+
+func readNumber(query string, i int) int {
+	if strings.HasPrefix(query[i:], "0x") || strings.HasPrefix(query[i:], "0X") {
+		i += 2
+		for i < len(query) && strings.IndexByte("0123456789abcdefABCDEF", query[i]) != -1 {
+			i++
+		}
+		return i
+	}
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		var j = i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+		if j < len(query) && isDigit(query[j]) {
+			i = j
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// isDollarQuoteStart returns true for "$$" or "$tag$".
+func isDollarQuoteStart(str string) bool {
+	for i := 1; i < len(str); i++ {
+		if str[i] == '$' {
+			return true
+		}
+		if !isWordStart(str[i]) {
+			return false
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c) || c == '$'
+}
+
+//------------------------------------------------------------------------------
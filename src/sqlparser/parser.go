@@ -0,0 +1,1093 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Error is a syntax error in a query.
+type Error struct {
+	Offset  int // in bytes in the query
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (at position %d)", e.Message, e.Offset)
+}
+
+//------------------------------------------------------------------------------
+
+type parser struct {
+	tokens  []token
+	index   int
+	dialect *Dialect
+}
+
+// Parse parses a query which may contain several statements separated by ";".
+// The returned error, if any, is an *Error.
+func Parse(query string, dialect *Dialect) (stmts []Statement, err error) {
+
+	var tokens, errTokenize = tokenize(query, dialect)
+	if errTokenize != nil {
+		return nil, errTokenize
+	}
+
+	var p = &parser{tokens: tokens, dialect: dialect}
+	defer func() {
+		if r := recover(); r != nil {
+			if errParse, ok := r.(*Error); ok {
+				stmts, err = nil, errParse
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	for {
+		for p.acceptOp(";") {
+		}
+		if p.peek().kind == tokEOF {
+			break
+		}
+		stmts = append(stmts, p.parseStatement())
+		if !p.isOp(p.peek(), ";") && p.peek().kind != tokEOF {
+			p.fail("unexpected %s", p.peek())
+		}
+	}
+	return stmts, nil
+}
+
+//------------------------------------------------------------------------------
+
+// StatementText is a statement of a text containing several statements, see SplitStatements.
+type StatementText struct {
+	Text   string
+	Offset int // in bytes in the text
+}
+
+// SplitStatements splits a text (e.g. a .sql file) into statements separated by ";", without
+// parsing them, so that one invalid statement does not prevent from using the others.
+func SplitStatements(text string, dialect *Dialect) ([]StatementText, error) {
+	var tokens, err = tokenize(text, dialect)
+	if err != nil {
+		return nil, err
+	}
+	var stmts []StatementText
+	var begin = -1
+	for _, t := range tokens {
+		if t.kind == tokEOF || (t.kind == tokOperator && t.value == ";") {
+			if begin != -1 {
+				stmts = append(stmts, StatementText{Text: text[begin:t.offset], Offset: begin})
+				begin = -1
+			}
+		} else if begin == -1 {
+			begin = t.offset
+		}
+	}
+	return stmts, nil
+}
+
+//------------------------------------------------------------------------------
+// tokens
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) peekN(n int) token {
+	if p.index+n < len(p.tokens) {
+		return p.tokens[p.index+n]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *parser) next() token {
+	var t = p.tokens[p.index]
+	if t.kind != tokEOF {
+		p.index++
+	}
+	return t
+}
+
+func (p *parser) isWord(t token, words ...string) bool {
+	if t.kind != tokWord {
+		return false
+	}
+	for _, word := range words {
+		if t.value == word {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) isOp(t token, op string) bool {
+	return t.kind == tokOperator && t.value == op
+}
+
+// acceptWord consumes the next token if it is one of these words.
+func (p *parser) acceptWord(words ...string) bool {
+	if p.isWord(p.peek(), words...) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// acceptWords consumes the next tokens if they are these words, in this order.
+func (p *parser) acceptWords(words ...string) bool {
+	for i, word := range words {
+		if !p.isWord(p.peekN(i), word) {
+			return false
+		}
+	}
+	p.index += len(words)
+	return true
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(p.peek(), op) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectWord(words ...string) token {
+	if !p.isWord(p.peek(), words...) {
+		p.fail("expected %s, found %s", strings.Join(words, " or "), p.peek())
+	}
+	return p.next()
+}
+
+func (p *parser) expectOp(op string) token {
+	if !p.isOp(p.peek(), op) {
+		p.fail("expected \"%s\", found %s", op, p.peek())
+	}
+	return p.next()
+}
+
+// fail stops the parsing with an error at the position of the next token.
+func (p *parser) fail(message string, args ...interface{}) {
+	panic(&Error{Offset: p.peek().offset, Message: fmt.Sprintf(message, args...)})
+}
+
+// skipParens skips a parenthesized block, e.g. "(foo (bar))".
+func (p *parser) skipParens() {
+	p.expectOp("(")
+	for depth := 1; depth > 0; {
+		var t = p.next()
+		if t.kind == tokEOF {
+			p.fail("expected \")\", found %s", t)
+		} else if p.isOp(t, "(") {
+			depth++
+		} else if p.isOp(t, ")") {
+			depth--
+		}
+	}
+}
+
+// skipUntil skips the tokens until one of these operators (or the end of the statement),
+// not counting those within parentheses.
+func (p *parser) skipUntil(ops ...string) {
+	for {
+		var t = p.peek()
+		if t.kind == tokEOF || p.isOp(t, ";") {
+			return
+		}
+		for _, op := range ops {
+			if p.isOp(t, op) {
+				return
+			}
+		}
+		if p.isOp(t, "(") {
+			p.skipParens()
+		} else if p.isOp(t, ")") {
+			return
+		} else {
+			p.next()
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// identifiers
+
+// words which are not reserved but cannot be used as an alias without AS
+var noImplicitAliasWords = makeWordSet(
+	"WINDOW", "LOCK", "FETCH", "RETURNING", "LATERAL", "STRAIGHT_JOIN", "OVER", "FILTER", "ESCAPE", "COLLATE",
+	"GLOB", "ILIKE", "REGEXP", "RLIKE", "SIMILAR", "DIV", "MOD", "ISNULL", "NOTNULL", "INDEXED", "USE", "IGNORE",
+	"FORCE", "PARTITION", "XOR", "MATCH",
+)
+
+func (p *parser) isIdent(t token) bool {
+	return t.kind == tokQuotedIdent || (t.kind == tokWord && !p.dialect.isReserved(t.value))
+}
+
+func (p *parser) parseIdent() string {
+	var t = p.peek()
+	if !p.isIdent(t) {
+		p.fail("expected an identifier, found %s", t)
+	}
+	p.next()
+	if t.kind == tokQuotedIdent {
+		return t.value
+	}
+	return t.text
+}
+
+// parseIdentAfterDot parses the part of a name after a ".", which may be a reserved word.
+func (p *parser) parseIdentAfterDot() string {
+	var t = p.peek()
+	if t.kind != tokQuotedIdent && t.kind != tokWord {
+		p.fail("expected an identifier, found %s", t)
+	}
+	p.next()
+	if t.kind == tokQuotedIdent {
+		return t.value
+	}
+	return t.text
+}
+
+// parseQualifiedName parses "foo" or "foo.bar".
+func (p *parser) parseQualifiedName() (schema string, name string) {
+	name = p.parseIdent()
+	for p.isOp(p.peek(), ".") && p.peekN(1).kind != tokOperator {
+		p.next()
+		schema, name = name, p.parseIdentAfterDot()
+	}
+	return
+}
+
+func (p *parser) parseIdentList() (names []string) {
+	for {
+		names = append(names, p.parseIdent())
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+// parseOptionalAlias parses "AS foo" or "foo", or returns "".
+func (p *parser) parseOptionalAlias() string {
+	if p.acceptWord("AS") {
+		if p.peek().kind == tokString {
+			return p.next().value
+		}
+		return p.parseIdent()
+	}
+	var t = p.peek()
+	if p.isIdent(t) && !(t.kind == tokWord && noImplicitAliasWords[t.value]) {
+		return p.parseIdent()
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+// statements
+
+// first words of the statements which are accepted without being analyzed
+var otherStatementWords = makeWordSet(
+	"ABORT", "ALTER", "ANALYZE", "ATTACH", "BEGIN", "CALL", "CHECKPOINT", "CLOSE", "CLUSTER", "COMMENT", "COMMIT",
+	"COPY", "CREATE", "DEALLOCATE", "DECLARE", "DESC", "DESCRIBE", "DETACH", "DISCARD", "DO", "END", "EXECUTE",
+	"EXPLAIN", "FETCH", "FLUSH", "GRANT", "HANDLER", "KILL", "LISTEN", "LOAD", "LOCK", "MERGE", "NOTIFY", "OPTIMIZE",
+	"PRAGMA", "PREPARE", "REFRESH", "REINDEX", "RELEASE", "RENAME", "REPAIR", "RESET", "REVOKE", "ROLLBACK",
+	"SAVEPOINT", "SECURITY", "SET", "SHOW", "START", "UNLISTEN", "UNLOCK", "USE", "VACUUM", "VALUES",
+)
+
+func (p *parser) parseStatement() Statement {
+
+	var t = p.peek()
+
+	if p.isWord(t, "WITH") {
+		var with = p.parseWith()
+		switch stmt := p.parseStatement().(type) {
+		case *SelectStmt:
+			stmt.With = with
+			return stmt
+		case *InsertStmt:
+			stmt.With = with
+			return stmt
+		case *UpdateStmt:
+			stmt.With = with
+			return stmt
+		case *DeleteStmt:
+			stmt.With = with
+			return stmt
+		default:
+			panic(&Error{Offset: stmt.Pos(), Message: "expected SELECT, INSERT, UPDATE or DELETE after WITH"})
+		}
+	}
+
+	switch {
+	case p.isWord(t, "SELECT") || p.isOp(t, "("):
+		return p.parseSelect()
+	case p.isWord(t, "INSERT", "REPLACE"):
+		return p.parseInsert()
+	case p.isWord(t, "UPDATE"):
+		return p.parseUpdate()
+	case p.isWord(t, "DELETE"):
+		return p.parseDelete()
+	case p.isWord(t, "CREATE") && p.isCreateTable():
+		return p.parseCreateTable()
+	case p.isWord(t, "CREATE") && p.isCreateView():
+		return p.parseCreateView()
+	case p.isWord(t, "ALTER") && p.isWord(p.peekN(1), "TABLE"):
+		return p.parseAlterTable()
+	case p.isWord(t, "DROP"):
+		return p.parseDrop()
+	case p.isWord(t, "TRUNCATE"):
+		return p.parseTruncate()
+	case t.kind == tokWord && otherStatementWords[t.value]:
+		p.skipUntil(";")
+		return &OtherStmt{Offset: t.offset, Keyword: t.value}
+	case t.kind == tokWord:
+		p.fail("unknown statement %q", t.text)
+	}
+	p.fail("expected a statement, found %s", t)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseWith parses "WITH [RECURSIVE] foo [(a, b)] AS (...), ...".
+func (p *parser) parseWith() (ctes []*CommonTableExpr) {
+	p.expectWord("WITH")
+	p.acceptWord("RECURSIVE")
+	for {
+		var cte = &CommonTableExpr{Name: p.parseIdent()}
+		if p.acceptOp("(") {
+			cte.Columns = p.parseIdentList()
+			p.expectOp(")")
+		}
+		p.expectWord("AS")
+		p.acceptWords("NOT", "MATERIALIZED")
+		p.acceptWord("MATERIALIZED")
+		p.expectOp("(")
+		cte.Stmt = p.parseStatement()
+		p.expectOp(")")
+		ctes = append(ctes, cte)
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// parseSelect parses a SELECT, with UNION/INTERSECT/EXCEPT, ORDER BY, LIMIT ...
+func (p *parser) parseSelect() *SelectStmt {
+
+	if p.isWord(p.peek(), "WITH") {
+		var with = p.parseWith()
+		var s = p.parseSelect()
+		s.With = with
+		return s
+	}
+
+	var s = p.parseSelectCore()
+	var current = s
+	for p.isWord(p.peek(), "UNION", "INTERSECT", "EXCEPT") || (p.dialect.Name == "mysql" && p.isWord(p.peek(), "MINUS")) {
+		var operator = p.next().value
+		if p.isWord(p.peek(), "ALL", "DISTINCT") {
+			operator += " " + p.next().value
+		}
+		var right = p.parseSelectCore()
+		current.Compound = &CompoundSelect{Operator: operator, Select: right}
+		current = right
+	}
+
+	if p.acceptWords("ORDER", "BY") {
+		s.OrderBy = p.parseOrderBy()
+	}
+	s.Limit, s.LimitOffset = p.parseLimit()
+	if p.acceptWord("FETCH") { // FETCH FIRST 10 ROWS ONLY
+		p.expectWord("FIRST", "NEXT")
+		if !p.isWord(p.peek(), "ROW", "ROWS") {
+			s.Limit = p.parseExpr()
+		}
+		p.expectWord("ROW", "ROWS")
+		if !p.acceptWord("ONLY") {
+			p.expectWord("WITH")
+			p.expectWord("TIES")
+		}
+	}
+	p.parseLockingClause()
+	return s
+}
+
+// parseSelectCore parses "SELECT ... FROM ... WHERE ... GROUP BY ... HAVING ..." or "(SELECT ...)".
+func (p *parser) parseSelectCore() *SelectStmt {
+
+	if p.acceptOp("(") {
+		var s = p.parseSelect()
+		p.expectOp(")")
+		return s
+	}
+	if p.dialect.Name != "mysql" && p.isWord(p.peek(), "VALUES") { // VALUES (1, 2), (3, 4)
+		var s = &SelectStmt{Offset: p.next().offset}
+		for _, row := range p.parseValuesRows() {
+			s.Columns = append(s.Columns, &SelectColumn{Offset: row[0].Pos(), Expr: &ListExpr{Offset: row[0].Pos(), Items: row}})
+		}
+		return s
+	}
+
+	var s = &SelectStmt{Offset: p.expectWord("SELECT").offset}
+
+	if p.acceptWord("DISTINCT") {
+		s.Distinct = true
+		if p.dialect.Name == "postgres" && p.acceptWord("ON") {
+			p.expectOp("(")
+			p.parseExprList()
+			p.expectOp(")")
+		}
+	} else {
+		p.acceptWord("ALL")
+	}
+	if p.dialect.Name == "mysql" {
+		for p.acceptWord("SQL_CALC_FOUND_ROWS", "SQL_NO_CACHE", "SQL_CACHE", "HIGH_PRIORITY", "STRAIGHT_JOIN",
+			"SQL_SMALL_RESULT", "SQL_BIG_RESULT", "SQL_BUFFER_RESULT") {
+		}
+	}
+
+	s.Columns = p.parseSelectColumns()
+
+	if p.acceptWord("FROM") {
+		s.From = p.parseTableRefs()
+	}
+	if p.acceptWord("WHERE") {
+		s.Where = p.parseExpr()
+	}
+	if p.acceptWords("GROUP", "BY") {
+		s.GroupBy = p.parseExprList()
+		p.acceptWords("WITH", "ROLLUP")
+	}
+	if p.acceptWord("HAVING") {
+		s.Having = p.parseExpr()
+	}
+	if p.acceptWord("WINDOW") {
+		for {
+			p.parseIdent()
+			p.expectWord("AS")
+			p.parseWindowSpec()
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	return s
+}
+
+func (p *parser) parseSelectColumns() (columns []*SelectColumn) {
+	for {
+		columns = append(columns, p.parseSelectColumn())
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+func (p *parser) parseSelectColumn() *SelectColumn {
+	var t = p.peek()
+	if p.acceptOp("*") {
+		return &SelectColumn{Offset: t.offset, Star: true}
+	}
+	if p.isIdent(t) && p.isOp(p.peekN(1), ".") && p.isOp(p.peekN(2), "*") {
+		var name = p.parseIdent()
+		p.next()
+		p.next()
+		return &SelectColumn{Offset: t.offset, Star: true, StarTable: name}
+	}
+	var column = &SelectColumn{Offset: t.offset, Expr: p.parseExpr()}
+	column.Alias = p.parseOptionalAlias()
+	return column
+}
+
+func (p *parser) parseOrderBy() (items []*OrderByItem) {
+	for {
+		var item = &OrderByItem{Expr: p.parseExpr()}
+		if p.acceptWord("DESC") {
+			item.Desc = true
+		} else {
+			p.acceptWord("ASC")
+		}
+		if p.acceptWord("NULLS") {
+			p.expectWord("FIRST", "LAST")
+		}
+		items = append(items, item)
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+// parseLimit parses "LIMIT n [OFFSET m]", "LIMIT m, n" or "OFFSET m [ROWS]".
+func (p *parser) parseLimit() (limit Expr, offset Expr) {
+	if p.acceptWord("LIMIT") {
+		if !p.acceptWord("ALL") {
+			limit = p.parseExpr()
+			if p.dialect.Name != "postgres" && p.acceptOp(",") {
+				offset, limit = limit, p.parseExpr()
+			}
+		}
+	}
+	if p.acceptWord("OFFSET") {
+		offset = p.parseExpr()
+		p.acceptWord("ROW", "ROWS")
+	}
+	return
+}
+
+// parseLockingClause parses "FOR UPDATE [OF foo] [NOWAIT|SKIP LOCKED]" and the like.
+func (p *parser) parseLockingClause() {
+	for {
+		if p.acceptWords("LOCK", "IN", "SHARE", "MODE") {
+			continue
+		}
+		if !p.acceptWord("FOR") {
+			return
+		}
+		p.acceptWords("NO", "KEY")
+		p.expectWord("UPDATE", "SHARE", "KEY")
+		p.acceptWord("SHARE")
+		if p.acceptWord("OF") {
+			p.parseIdentList()
+		}
+		if !p.acceptWord("NOWAIT") {
+			p.acceptWords("SKIP", "LOCKED")
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// parseTableRefs parses the tables of a FROM clause, e.g. "a, b JOIN c ON ...".
+func (p *parser) parseTableRefs() []*TableRef {
+	var refs = []*TableRef{p.parseTableRef("")}
+	for {
+		if p.acceptOp(",") {
+			refs = append(refs, p.parseTableRef(","))
+			continue
+		}
+		var join = p.parseJoinKeyword()
+		if join == "" {
+			return refs
+		}
+		var ref = p.parseTableRef(join)
+		if p.acceptWord("ON") {
+			ref.On = p.parseExpr()
+		} else if p.acceptWord("USING") {
+			p.expectOp("(")
+			ref.Using = p.parseIdentList()
+			p.expectOp(")")
+		}
+		refs = append(refs, ref)
+	}
+}
+
+// parseJoinKeyword parses e.g. "LEFT OUTER JOIN" and returns it, or "" if there is no join.
+func (p *parser) parseJoinKeyword() string {
+	var words []string
+	if p.isWord(p.peek(), "NATURAL") {
+		words = append(words, p.next().value)
+	}
+	if p.isWord(p.peek(), "LEFT", "RIGHT", "FULL") {
+		words = append(words, p.next().value)
+		if p.isWord(p.peek(), "OUTER") {
+			words = append(words, p.next().value)
+		}
+	} else if p.isWord(p.peek(), "INNER", "CROSS") {
+		words = append(words, p.next().value)
+	}
+	if p.isWord(p.peek(), "JOIN", "STRAIGHT_JOIN") {
+		words = append(words, p.next().value)
+	} else if len(words) > 0 {
+		p.fail("expected JOIN, found %s", p.peek())
+	}
+	return strings.Join(words, " ")
+}
+
+// parseTableRef parses a table, a subquery or a parenthesized join, with its alias.
+func (p *parser) parseTableRef(join string) *TableRef {
+
+	var ref = &TableRef{Offset: p.peek().offset, Join: join}
+	p.acceptWord("LATERAL")
+	p.acceptWord("ONLY")
+
+	if p.acceptOp("(") {
+		if p.isWord(p.peek(), "SELECT", "WITH", "VALUES") || p.isOp(p.peek(), "(") && p.isWord(p.peekN(1), "SELECT") {
+			ref.Subquery = p.parseSelect()
+		} else {
+			ref.Nested = p.parseTableRefs()
+		}
+		p.expectOp(")")
+	} else {
+		var offset = p.peek().offset
+		ref.Schema, ref.Name = p.parseQualifiedName()
+		if p.isOp(p.peek(), "(") { // table function
+			ref.Function = p.parseFuncCall(strings.ToUpper(ref.Name), offset)
+			ref.Schema, ref.Name = "", ""
+		}
+	}
+
+	ref.Alias = p.parseOptionalAlias()
+	if ref.Alias != "" && p.isOp(p.peek(), "(") { // AS foo(a, b)
+		p.skipParens()
+	}
+
+	for p.dialect.Name == "mysql" && p.isWord(p.peek(), "USE", "IGNORE", "FORCE") &&
+		p.isWord(p.peekN(1), "INDEX", "KEY") { // index hints
+		p.next()
+		p.next()
+		if p.acceptWord("FOR") {
+			p.acceptWord("JOIN")
+			p.acceptWords("ORDER", "BY")
+			p.acceptWords("GROUP", "BY")
+		}
+		p.skipParens()
+	}
+	if p.dialect.Name == "sqlite" {
+		if p.acceptWords("INDEXED", "BY") {
+			p.parseIdent()
+		} else {
+			p.acceptWords("NOT", "INDEXED")
+		}
+	}
+	return ref
+}
+
+//------------------------------------------------------------------------------
+
+// parseInsert parses an INSERT or a REPLACE.
+func (p *parser) parseInsert() *InsertStmt {
+
+	var s = &InsertStmt{Offset: p.next().offset}
+
+	if p.dialect.Name == "mysql" {
+		for p.acceptWord("LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "IGNORE") {
+		}
+	} else if p.dialect.Name == "sqlite" && p.acceptWord("OR") {
+		p.expectWord("REPLACE", "ROLLBACK", "ABORT", "FAIL", "IGNORE")
+	}
+	if p.dialect.Name == "mysql" {
+		p.acceptWord("INTO")
+	} else {
+		p.expectWord("INTO")
+	}
+
+	s.Table = p.parseTableName()
+	if p.acceptWord("AS") {
+		s.Table.Alias = p.parseIdent()
+	}
+
+	if p.isOp(p.peek(), "(") && !p.isWord(p.peekN(1), "SELECT", "WITH") {
+		p.next()
+		for {
+			s.Columns = append(s.Columns, p.parseColumnName())
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		p.expectOp(")")
+	}
+
+	switch {
+	case p.acceptWord("VALUES") || (p.dialect.Name == "mysql" && p.acceptWord("VALUE")):
+		s.Rows = p.parseValuesRows()
+	case p.isWord(p.peek(), "SELECT", "WITH") || p.isOp(p.peek(), "("):
+		s.Select = p.parseSelect()
+	case p.dialect.Name == "mysql" && p.acceptWord("SET"):
+		s.Set = p.parseAssignments()
+	case p.dialect.Name != "mysql" && p.acceptWords("DEFAULT", "VALUES"):
+		s.DefaultValues = true
+	default:
+		p.fail("expected VALUES or SELECT, found %s", p.peek())
+	}
+
+	if p.dialect.Name == "mysql" {
+		if p.acceptWords("ON", "DUPLICATE", "KEY", "UPDATE") {
+			s.OnConflict = p.parseAssignments()
+		}
+	} else if p.acceptWords("ON", "CONFLICT") {
+		if p.isOp(p.peek(), "(") {
+			p.skipParens()
+			if p.acceptWord("WHERE") {
+				p.parseExpr()
+			}
+		} else if p.acceptWords("ON", "CONSTRAINT") {
+			p.parseIdent()
+		}
+		p.expectWord("DO")
+		if !p.acceptWord("NOTHING") {
+			p.expectWord("UPDATE")
+			p.expectWord("SET")
+			s.OnConflict = p.parseAssignments()
+			if p.acceptWord("WHERE") {
+				p.parseExpr()
+			}
+		}
+	}
+
+	s.Returning = p.parseReturning()
+	return s
+}
+
+// parseValuesRows parses "(1, 2), (3, 4)".
+func (p *parser) parseValuesRows() (rows [][]Expr) {
+	for {
+		if p.dialect.Name == "mysql" {
+			p.acceptWord("ROW")
+		}
+		p.expectOp("(")
+		var row []Expr
+		if !p.isOp(p.peek(), ")") {
+			row = p.parseExprList()
+		}
+		p.expectOp(")")
+		rows = append(rows, row)
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+// parseReturning parses "RETURNING a, b" or returns nil.
+func (p *parser) parseReturning() []*SelectColumn {
+	if p.dialect.Name != "mysql" && p.acceptWord("RETURNING") {
+		return p.parseSelectColumns()
+	}
+	return nil
+}
+
+// parseTableName parses the name of a table, e.g. "foo" or "foo.bar".
+func (p *parser) parseTableName() *TableRef {
+	var ref = &TableRef{Offset: p.peek().offset}
+	ref.Schema, ref.Name = p.parseQualifiedName()
+	return ref
+}
+
+// parseColumnName parses the name of a column, e.g. "foo" or "t.foo".
+func (p *parser) parseColumnName() *ColumnRef {
+	var column = &ColumnRef{Offset: p.peek().offset}
+	column.Name = p.parseIdent()
+	for p.acceptOp(".") {
+		column.Schema, column.Table, column.Name = column.Table, column.Name, p.parseIdentAfterDot()
+	}
+	return column
+}
+
+func (p *parser) parseAssignments() (assignments []*Assignment) {
+	for {
+		var assignment = &Assignment{Column: p.parseColumnName()}
+		p.expectOp("=")
+		assignment.Value = p.parseExpr()
+		assignments = append(assignments, assignment)
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseUpdate() *UpdateStmt {
+
+	var s = &UpdateStmt{Offset: p.expectWord("UPDATE").offset}
+	if p.dialect.Name == "mysql" {
+		for p.acceptWord("LOW_PRIORITY", "IGNORE") {
+		}
+	} else if p.dialect.Name == "sqlite" && p.acceptWord("OR") {
+		p.expectWord("REPLACE", "ROLLBACK", "ABORT", "FAIL", "IGNORE")
+	}
+
+	s.Tables = p.parseTableRefs()
+	p.expectWord("SET")
+	s.Set = p.parseAssignments()
+	if p.dialect.Name != "mysql" && p.acceptWord("FROM") {
+		s.From = p.parseTableRefs()
+	}
+	if p.acceptWord("WHERE") {
+		s.Where = p.parseExpr()
+	}
+	if p.dialect.Name != "postgres" {
+		if p.acceptWords("ORDER", "BY") {
+			s.OrderBy = p.parseOrderBy()
+		}
+		s.Limit, _ = p.parseLimit()
+	}
+	s.Returning = p.parseReturning()
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseDelete() *DeleteStmt {
+
+	var s = &DeleteStmt{Offset: p.expectWord("DELETE").offset}
+	if p.dialect.Name == "mysql" {
+		for p.acceptWord("LOW_PRIORITY", "QUICK", "IGNORE") {
+		}
+		if !p.isWord(p.peek(), "FROM") { // DELETE a, b FROM ...
+			for {
+				var _, name = p.parseQualifiedName()
+				if p.acceptOp(".") {
+					p.expectOp("*")
+				}
+				s.Targets = append(s.Targets, name)
+				if !p.acceptOp(",") {
+					break
+				}
+			}
+		}
+	}
+
+	p.expectWord("FROM")
+	s.Tables = p.parseTableRefs()
+	if p.acceptWord("USING") {
+		s.Using = p.parseTableRefs()
+	}
+	if p.acceptWord("WHERE") {
+		s.Where = p.parseExpr()
+	}
+	if p.dialect.Name != "postgres" {
+		if p.acceptWords("ORDER", "BY") {
+			s.OrderBy = p.parseOrderBy()
+		}
+		s.Limit, _ = p.parseLimit()
+	}
+	s.Returning = p.parseReturning()
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) isCreateTable() bool {
+	for i := 1; i < 4; i++ {
+		var t = p.peekN(i)
+		if p.isWord(t, "TABLE") {
+			return true
+		}
+		if !p.isWord(t, "TEMPORARY", "TEMP", "UNLOGGED", "GLOBAL", "LOCAL") {
+			return false
+		}
+	}
+	return false
+}
+
+// isCreateView returns true for "CREATE [OR REPLACE] [ALGORITHM=...] [TEMPORARY] [MATERIALIZED] VIEW".
+func (p *parser) isCreateView() bool {
+	for i := 1; i < 12; i++ {
+		var t = p.peekN(i)
+		if p.isWord(t, "VIEW") {
+			return true
+		}
+		if t.kind == tokEOF || p.isOp(t, ";") || p.isOp(t, "(") || p.isWord(t, "TABLE", "INDEX", "AS") {
+			return false
+		}
+	}
+	return false
+}
+
+// parseCreateView parses a CREATE VIEW.
+func (p *parser) parseCreateView() *CreateViewStmt {
+	var s = &CreateViewStmt{Offset: p.expectWord("CREATE").offset}
+	for !p.acceptWord("VIEW") {
+		p.next()
+	}
+	p.acceptWords("IF", "NOT", "EXISTS")
+	s.View = p.parseTableName()
+	if p.acceptOp("(") {
+		s.Columns = p.parseIdentList()
+		p.expectOp(")")
+	}
+	p.expectWord("AS")
+	s.Select = p.parseSelect()
+	p.skipUntil() // WITH CHECK OPTION ...
+	return s
+}
+
+// parseCreateTable parses a CREATE TABLE; only the columns are kept, not the constraints nor the options.
+func (p *parser) parseCreateTable() *CreateTableStmt {
+
+	var s = &CreateTableStmt{Offset: p.expectWord("CREATE").offset}
+	for p.acceptWord("TEMPORARY", "TEMP", "UNLOGGED", "GLOBAL", "LOCAL") {
+	}
+	p.expectWord("TABLE")
+	s.IfNotExists = p.acceptWords("IF", "NOT", "EXISTS")
+	s.Table = p.parseTableName()
+
+	if p.acceptWord("AS") {
+		s.AsSelect = p.parseSelect()
+		return s
+	}
+	if p.acceptWord("LIKE") {
+		p.parseQualifiedName()
+		return s
+	}
+
+	p.expectOp("(")
+	for {
+		if p.isWord(p.peek(), "CONSTRAINT", "PRIMARY", "UNIQUE", "KEY", "INDEX", "FOREIGN", "CHECK", "FULLTEXT",
+			"SPATIAL", "EXCLUDE") {
+			p.skipUntil(",")
+		} else {
+			s.Columns = append(s.Columns, p.parseColumnDef())
+		}
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(")")
+
+	p.skipUntil() // table options
+	return s
+}
+
+// parseColumnDef parses e.g. "id INT NOT NULL AUTO_INCREMENT".
+func (p *parser) parseColumnDef() *ColumnDef {
+	var def = &ColumnDef{Offset: p.peek().offset}
+	if p.peek().kind == tokWord { // column names may be non-reserved keywords
+		def.Name = p.next().text
+	} else {
+		def.Name = p.parseIdent()
+	}
+	if !p.isOp(p.peek(), ",") && !p.isOp(p.peek(), ")") && !p.isWord(p.peek(), "PRIMARY", "NOT", "NULL", "DEFAULT", "UNIQUE", "REFERENCES") {
+		def.Type = p.parseTypeName()
+	}
+	p.skipUntil(",") // constraints
+	return def
+}
+
+// words which may follow the first word of a type, e.g. "DOUBLE PRECISION"
+var typeContinuationWords = makeWordSet("PRECISION", "VARYING", "UNSIGNED", "SIGNED", "ZEROFILL", "INTEGER")
+
+// parseTypeName parses e.g. "VARCHAR(255)", "DOUBLE PRECISION", "TIMESTAMP WITH TIME ZONE" or "INT[]".
+func (p *parser) parseTypeName() string {
+	var t = p.peek()
+	if t.kind != tokWord && t.kind != tokQuotedIdent {
+		p.fail("expected a type, found %s", t)
+	}
+	var words = []string{strings.ToUpper(p.parseIdentAfterDot())}
+	for {
+		if p.isOp(p.peek(), "(") {
+			var begin = p.index
+			p.skipParens()
+			var args []string
+			for _, t2 := range p.tokens[begin+1 : p.index-1] {
+				args = append(args, t2.text)
+			}
+			words[len(words)-1] += "(" + strings.Join(args, "") + ")"
+		} else if p.peek().kind == tokWord && typeContinuationWords[p.peek().value] {
+			words = append(words, p.next().value)
+		} else if p.isWord(p.peek(), "WITH", "WITHOUT") && p.isWord(p.peekN(1), "TIME") {
+			words = append(words, p.next().value, p.next().value)
+			words = append(words, p.expectWord("ZONE").value)
+		} else if p.isOp(p.peek(), "[") && p.isOp(p.peekN(1), "]") {
+			p.next()
+			p.next()
+			words[len(words)-1] += "[]"
+		} else if p.isOp(p.peek(), ".") { // schema.type
+			p.next()
+			words[len(words)-1] += "." + strings.ToUpper(p.parseIdentAfterDot())
+		} else {
+			return strings.Join(words, " ")
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseAlterTable() *AlterTableStmt {
+
+	var s = &AlterTableStmt{Offset: p.expectWord("ALTER").offset, RenameColumns: make(map[string]string)}
+	p.expectWord("TABLE")
+	p.acceptWords("IF", "EXISTS")
+	p.acceptWord("ONLY")
+	s.Table = p.parseTableName()
+
+	for {
+		switch {
+		case p.isWord(p.peek(), "ADD") && !p.isWord(p.peekN(1), "CONSTRAINT", "PRIMARY", "UNIQUE", "KEY", "INDEX",
+			"FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "PARTITION"):
+			p.next()
+			p.acceptWord("COLUMN")
+			p.acceptWords("IF", "NOT", "EXISTS")
+			s.AddColumns = append(s.AddColumns, p.parseColumnDef())
+		case p.isWord(p.peek(), "DROP") && !p.isWord(p.peekN(1), "CONSTRAINT", "PRIMARY", "INDEX", "KEY", "FOREIGN",
+			"CHECK", "PARTITION", "DEFAULT"):
+			p.next()
+			p.acceptWord("COLUMN")
+			p.acceptWords("IF", "EXISTS")
+			s.DropColumns = append(s.DropColumns, p.parseIdentAfterDot())
+			p.skipUntil(",")
+		case p.acceptWord("RENAME"):
+			if p.acceptWord("TO", "AS") {
+				var _, name = p.parseQualifiedName()
+				s.RenameTo = name
+			} else if !p.isWord(p.peek(), "INDEX", "KEY", "CONSTRAINT") {
+				p.acceptWord("COLUMN")
+				var oldName = p.parseIdentAfterDot()
+				p.expectWord("TO")
+				s.RenameColumns[oldName] = p.parseIdentAfterDot()
+			}
+			p.skipUntil(",")
+		case p.dialect.Name == "mysql" && p.acceptWord("CHANGE"):
+			p.acceptWord("COLUMN")
+			var oldName = p.parseIdentAfterDot()
+			var def = p.parseColumnDef()
+			if def.Name != oldName {
+				s.RenameColumns[oldName] = def.Name
+			}
+		default:
+			p.skipUntil(",")
+		}
+		if !p.acceptOp(",") {
+			return s
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseDrop() *DropStmt {
+	var s = &DropStmt{Offset: p.expectWord("DROP").offset}
+	p.acceptWord("TEMPORARY")
+	var t = p.next()
+	if t.kind != tokWord {
+		panic(&Error{Offset: t.offset, Message: fmt.Sprintf("expected TABLE, VIEW, INDEX ..., found %s", t)})
+	}
+	s.Kind = t.value
+	if s.Kind == "MATERIALIZED" {
+		s.Kind += " " + p.expectWord("VIEW").value
+	}
+	p.acceptWord("CONCURRENTLY")
+	p.acceptWords("IF", "EXISTS")
+	for {
+		var _, name = p.parseQualifiedName()
+		s.Names = append(s.Names, name)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.skipUntil()
+	return s
+}
+
+func (p *parser) parseTruncate() *TruncateStmt {
+	var s = &TruncateStmt{Offset: p.expectWord("TRUNCATE").offset}
+	p.acceptWord("TABLE")
+	p.acceptWord("ONLY")
+	for {
+		var _, name = p.parseQualifiedName()
+		s.Tables = append(s.Tables, name)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.skipUntil()
+	return s
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,504 @@
+package sqlparser
+
+import (
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// binary operators by precedence level (lowest first), after the comparisons
+var binaryOperatorLevels = [][]string{
+	{"|"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-", "||"},
+	{"*", "/", "%", "DIV", "MOD"},
+	{"^"},
+}
+
+var comparisonOperators = makeWordSet("=", "<>", "!=", "<", "<=", ">", ">=", "<=>", "~", "~*", "!~", "!~*", ":=")
+
+// words followed by "(" which are not function calls
+var nonFunctionWords = makeWordSet(
+	"SELECT", "FROM", "WHERE", "GROUP", "ORDER", "HAVING", "LIMIT", "UNION", "JOIN", "ON", "USING", "AS", "AND",
+	"OR", "IN", "IS", "LIKE", "INTO", "SET", "THEN", "WHEN", "ELSE", "END", "BY", "NOT", "EXISTS", "CASE", "CAST",
+	"ARRAY", "INTERVAL",
+)
+
+// words which are values when not followed by "("
+var keywordValues = makeWordSet(
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "LOCALTIME", "LOCALTIMESTAMP", "CURRENT_USER",
+	"SESSION_USER", "CURRENT_ROLE", "CURRENT_SCHEMA", "UTC_DATE", "UTC_TIME", "UTC_TIMESTAMP",
+)
+
+var intervalUnits = makeWordSet(
+	"MICROSECOND", "SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "QUARTER", "YEAR", "SECOND_MICROSECOND",
+	"MINUTE_MICROSECOND", "MINUTE_SECOND", "HOUR_MICROSECOND", "HOUR_SECOND", "HOUR_MINUTE", "DAY_MICROSECOND",
+	"DAY_SECOND", "DAY_MINUTE", "DAY_HOUR", "YEAR_MONTH",
+)
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parseExpr() Expr {
+	return p.parseOr()
+}
+
+func (p *parser) parseExprList() (exprs []Expr) {
+	for {
+		exprs = append(exprs, p.parseExpr())
+		if !p.acceptOp(",") {
+			return
+		}
+	}
+}
+
+func (p *parser) parseOr() Expr {
+	var left = p.parseAnd()
+	for {
+		if p.acceptWord("OR") {
+			left = &BinaryExpr{Operator: "OR", Left: left, Right: p.parseAnd()}
+		} else if p.dialect.Name == "mysql" && p.acceptWord("XOR") {
+			left = &BinaryExpr{Operator: "XOR", Left: left, Right: p.parseAnd()}
+		} else {
+			return left
+		}
+	}
+}
+
+func (p *parser) parseAnd() Expr {
+	var left = p.parseNot()
+	for p.acceptWord("AND") || (p.dialect.Name == "mysql" && p.acceptOp("&&")) {
+		left = &BinaryExpr{Operator: "AND", Left: left, Right: p.parseNot()}
+	}
+	return left
+}
+
+func (p *parser) parseNot() Expr {
+	if p.isWord(p.peek(), "NOT") {
+		var t = p.next()
+		return &UnaryExpr{Offset: t.offset, Operator: "NOT", X: p.parseNot()}
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses "a = b", "a IS NULL", "a IN (...)", "a BETWEEN b AND c", "a LIKE b" ...
+func (p *parser) parseComparison() Expr {
+
+	var left = p.parseBinary(0)
+	for {
+		var t = p.peek()
+
+		if t.kind == tokOperator && comparisonOperators[t.value] {
+			p.next()
+			left = &BinaryExpr{Operator: t.value, Left: left, Right: p.parseBinary(0)}
+			continue
+		}
+
+		if p.acceptWord("IS") {
+			var e = &IsExpr{X: left, Not: p.acceptWord("NOT")}
+			if p.acceptWords("DISTINCT", "FROM") {
+				e.Value = "DISTINCT FROM"
+				e.Other = p.parseBinary(0)
+			} else {
+				e.Value = p.expectWord("NULL", "TRUE", "FALSE", "UNKNOWN").value
+			}
+			left = e
+			continue
+		}
+		if p.dialect.Name != "mysql" && p.isWord(t, "ISNULL", "NOTNULL") {
+			p.next()
+			left = &IsExpr{X: left, Not: t.value == "NOTNULL", Value: "NULL"}
+			continue
+		}
+
+		var not = false
+		if p.isWord(t, "NOT") && p.isWord(p.peekN(1), "IN", "BETWEEN", "LIKE", "ILIKE", "REGEXP", "RLIKE", "GLOB", "SIMILAR", "MATCH") {
+			p.next()
+			not = true
+			t = p.peek()
+		}
+
+		switch {
+		case p.acceptWord("IN"):
+			var e = &InExpr{X: left, Not: not}
+			p.expectOp("(")
+			if p.isWord(p.peek(), "SELECT", "WITH") {
+				e.Select = p.parseSelect()
+			} else if !p.isOp(p.peek(), ")") {
+				e.List = p.parseExprList()
+			}
+			p.expectOp(")")
+			left = e
+		case p.acceptWord("BETWEEN"):
+			var e = &BetweenExpr{X: left, Not: not, Low: p.parseBinary(0)}
+			p.expectWord("AND")
+			e.High = p.parseBinary(0)
+			left = e
+		case p.isWord(t, "LIKE") || (p.dialect.Name == "postgres" && p.isWord(t, "ILIKE", "SIMILAR")) ||
+			(p.dialect.Name == "mysql" && p.isWord(t, "REGEXP", "RLIKE")) ||
+			(p.dialect.Name == "sqlite" && p.isWord(t, "GLOB", "REGEXP", "MATCH")):
+			p.next()
+			var operator = t.value
+			if operator == "SIMILAR" {
+				operator += " " + p.expectWord("TO").value
+			}
+			if not {
+				operator = "NOT " + operator
+			}
+			left = &BinaryExpr{Operator: operator, Left: left, Right: p.parseBinary(0)}
+			if p.acceptWord("ESCAPE") {
+				p.parseBinary(0)
+			}
+		default:
+			if not {
+				p.fail("expected IN, BETWEEN or LIKE, found %s", p.peek())
+			}
+			return left
+		}
+	}
+}
+
+// parseBinary parses the binary operators from this precedence level.
+func (p *parser) parseBinary(level int) Expr {
+	if level == len(binaryOperatorLevels) {
+		return p.parseUnary()
+	}
+	var left = p.parseBinary(level + 1)
+	for {
+		var t = p.peek()
+		var found = false
+		for _, operator := range binaryOperatorLevels[level] {
+			if (t.kind == tokOperator || (t.kind == tokWord && p.dialect.Name == "mysql")) && t.value == operator {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return left
+		}
+		p.next()
+		left = &BinaryExpr{Operator: t.value, Left: left, Right: p.parseBinary(level + 1)}
+	}
+}
+
+func (p *parser) parseUnary() Expr {
+	var t = p.peek()
+	if t.kind == tokOperator && (t.value == "-" || t.value == "+" || t.value == "~" || (t.value == "!" && p.dialect.Name == "mysql")) {
+		p.next()
+		return &UnaryExpr{Offset: t.offset, Operator: t.value, X: p.parseUnary()}
+	}
+	if p.dialect.Name == "mysql" && p.isWord(t, "BINARY") && !p.isOp(p.peekN(1), "(") {
+		p.next()
+		return &UnaryExpr{Offset: t.offset, Operator: "BINARY", X: p.parseUnary()}
+	}
+	return p.parsePostfix(p.parsePrimary())
+}
+
+// parsePostfix parses "a::type", "a COLLATE foo", "a[1]", "a->'b'" ...
+func (p *parser) parsePostfix(e Expr) Expr {
+	for {
+		var t = p.peek()
+		switch {
+		case p.dialect.doubleColonCast && p.isOp(t, "::"):
+			p.next()
+			e = &CastExpr{Offset: e.Pos(), X: e, Type: p.parseTypeName()}
+		case p.isWord(t, "COLLATE"):
+			p.next()
+			if p.peek().kind == tokString {
+				p.next()
+			} else {
+				p.parseQualifiedName()
+			}
+		case p.dialect.Name == "postgres" && p.isOp(t, "["):
+			p.next()
+			var index = p.parseExpr()
+			p.expectOp("]")
+			e = &BinaryExpr{Operator: "[]", Left: e, Right: index}
+		case p.isOp(t, "->") || p.isOp(t, "->>"):
+			p.next()
+			e = &BinaryExpr{Operator: t.value, Left: e, Right: p.parsePrimary()}
+		default:
+			return e
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (p *parser) parsePrimary() Expr {
+
+	var t = p.peek()
+
+	switch t.kind {
+
+	case tokNumber:
+		p.next()
+		return &Literal{Offset: t.offset, Kind: "number", Value: t.value}
+
+	case tokString:
+		p.next()
+		return &Literal{Offset: t.offset, Kind: "string", Value: t.value}
+
+	case tokPlaceholder:
+		p.next()
+		if strings.HasPrefix(t.text, "@") && p.dialect.Name == "mysql" {
+			var name = t.text
+			for p.acceptOp(".") {
+				name += "." + p.parseIdentAfterDot()
+			}
+			return &Variable{Offset: t.offset, Name: name}
+		}
+		var style = PlaceholderStyle(t.text)
+		if !p.dialect.placeholders[style] {
+			panic(&Error{Offset: t.offset, Message: "placeholder " + t.text + " is not supported by " + p.dialect.Name})
+		}
+		return &Placeholder{Offset: t.offset, Text: t.text}
+
+	case tokQuotedIdent:
+		return p.parseColumnRefOrFuncCall()
+
+	case tokOperator:
+		if p.isOp(t, "(") {
+			p.next()
+			if p.isWord(p.peek(), "SELECT", "WITH") {
+				var e = &SubqueryExpr{Offset: t.offset, Select: p.parseSelect()}
+				p.expectOp(")")
+				return e
+			}
+			var e = p.parseExpr()
+			if p.acceptOp(",") {
+				var list = &ListExpr{Offset: t.offset, Items: append([]Expr{e}, p.parseExprList()...)}
+				p.expectOp(")")
+				return list
+			}
+			p.expectOp(")")
+			return e
+		}
+
+	case tokWord:
+		switch {
+		case p.isWord(t, "NULL"):
+			p.next()
+			return &Literal{Offset: t.offset, Kind: "null", Value: t.value}
+		case p.isWord(t, "TRUE", "FALSE"):
+			p.next()
+			return &Literal{Offset: t.offset, Kind: "bool", Value: t.value}
+		case p.isWord(t, "DEFAULT") && !p.isOp(p.peekN(1), "("):
+			p.next()
+			return &Literal{Offset: t.offset, Kind: "default", Value: t.value}
+		case keywordValues[t.value] && !p.isOp(p.peekN(1), "("):
+			p.next()
+			return &Literal{Offset: t.offset, Kind: "keyword", Value: t.value}
+		case p.isWord(t, "DATE", "TIME", "TIMESTAMP") && p.peekN(1).kind == tokString: // DATE '2020-01-01'
+			p.next()
+			var value = p.next()
+			return &Literal{Offset: t.offset, Kind: "string", Value: value.value}
+		case p.isWord(t, "CASE"):
+			return p.parseCase()
+		case p.isWord(t, "CAST", "TRY_CAST") && p.isOp(p.peekN(1), "("):
+			p.next()
+			p.next()
+			var e = &CastExpr{Offset: t.offset, X: p.parseExpr()}
+			p.expectWord("AS")
+			e.Type = p.parseTypeName()
+			p.expectOp(")")
+			return e
+		case p.isWord(t, "EXISTS"):
+			p.next()
+			p.expectOp("(")
+			var e = &SubqueryExpr{Offset: t.offset, Exists: true, Select: p.parseSelect()}
+			p.expectOp(")")
+			return e
+		case p.isWord(t, "NOT"):
+			p.next()
+			return &UnaryExpr{Offset: t.offset, Operator: "NOT", X: p.parseUnary()}
+		case p.isWord(t, "INTERVAL"):
+			p.next()
+			var e = &IntervalExpr{Offset: t.offset, Value: p.parseUnary()}
+			if p.peek().kind == tokWord && intervalUnits[p.peek().value] {
+				e.Unit = p.next().value
+			}
+			return e
+		case p.dialect.Name == "postgres" && p.isWord(t, "ARRAY"):
+			p.next()
+			if p.isOp(p.peek(), "(") {
+				p.next()
+				var e = &SubqueryExpr{Offset: t.offset, Select: p.parseSelect()}
+				p.expectOp(")")
+				return e
+			}
+			p.expectOp("[")
+			var e = &ListExpr{Offset: t.offset}
+			if !p.isOp(p.peek(), "]") {
+				e.Items = p.parseExprList()
+			}
+			p.expectOp("]")
+			return e
+		case p.isOp(p.peekN(1), "(") && !nonFunctionWords[t.value]:
+			return p.parseColumnRefOrFuncCall()
+		case !p.dialect.isReserved(t.value):
+			return p.parseColumnRefOrFuncCall()
+		}
+	}
+
+	p.fail("expected an expression, found %s", t)
+	return nil
+}
+
+// parseColumnRefOrFuncCall parses "foo", "t.foo", "s.t.foo", "foo(...)" or "s.foo(...)".
+func (p *parser) parseColumnRefOrFuncCall() Expr {
+	var t = p.next()
+	var parts = []string{t.text}
+	if t.kind == tokQuotedIdent {
+		parts[0] = t.value
+	}
+	for p.isOp(p.peek(), ".") {
+		p.next()
+		parts = append(parts, p.parseIdentAfterDot())
+	}
+	if p.isOp(p.peek(), "(") {
+		return p.parseFuncCall(strings.ToUpper(strings.Join(parts, ".")), t.offset)
+	}
+	if len(parts) > 3 {
+		panic(&Error{Offset: t.offset, Message: "invalid column name " + strings.Join(parts, ".")})
+	}
+	var column = &ColumnRef{Offset: t.offset, Name: parts[len(parts)-1]}
+	if len(parts) >= 2 {
+		column.Table = parts[len(parts)-2]
+	}
+	if len(parts) == 3 {
+		column.Schema = parts[0]
+	}
+	return column
+}
+
+// parseFuncCall parses the arguments of a function call, and what may follow (OVER ...), from "(".
+func (p *parser) parseFuncCall(name string, offset int) *FuncCall {
+
+	var f = &FuncCall{Offset: offset, Name: name}
+	p.expectOp("(")
+
+	if p.acceptOp("*") {
+		f.Star = true
+	} else if !p.isOp(p.peek(), ")") {
+		if p.acceptWord("DISTINCT") {
+			f.Distinct = true
+		} else {
+			p.acceptWord("ALL")
+		}
+		switch name {
+		case "EXTRACT": // EXTRACT(YEAR FROM foo)
+			p.parseIdentAfterDot()
+			p.expectWord("FROM")
+		case "TRIM": // TRIM(LEADING 'x' FROM foo)
+			p.acceptWord("BOTH", "LEADING", "TRAILING")
+			p.acceptWord("FROM")
+		}
+		for {
+			f.Args = append(f.Args, p.parseExpr())
+			if p.acceptOp(",") || p.acceptWord("FROM", "FOR", "IN", "SEPARATOR") {
+				continue
+			}
+			if p.acceptWord("USING") { // CONVERT(foo USING utf8mb4)
+				p.parseIdentAfterDot()
+			} else if p.acceptWord("AS") { // CONVERT(foo AS type)
+				p.parseTypeName()
+			}
+			if p.acceptWords("ORDER", "BY") { // STRING_AGG(foo, ',' ORDER BY bar)
+				p.parseOrderBy()
+				if p.acceptWord("SEPARATOR") {
+					f.Args = append(f.Args, p.parseExpr())
+				}
+			}
+			break
+		}
+	}
+	p.expectOp(")")
+
+	if name == "MATCH" && p.acceptWord("AGAINST") { // MATCH (a, b) AGAINST ('foo' IN BOOLEAN MODE)
+		p.expectOp("(")
+		f.Args = append(f.Args, p.parseBinary(0))
+		p.skipUntil()
+		p.expectOp(")")
+	}
+	if p.acceptWords("WITHIN", "GROUP") {
+		p.expectOp("(")
+		p.expectWord("ORDER")
+		p.expectWord("BY")
+		p.parseOrderBy()
+		p.expectOp(")")
+	}
+	if p.acceptWord("FILTER") {
+		p.expectOp("(")
+		p.expectWord("WHERE")
+		p.parseExpr()
+		p.expectOp(")")
+	}
+	if p.acceptWord("OVER") {
+		if p.isOp(p.peek(), "(") {
+			p.parseWindowSpec()
+		} else {
+			p.parseIdent()
+		}
+	}
+	return f
+}
+
+// parseWindowSpec parses "(PARTITION BY a ORDER BY b ROWS BETWEEN ...)".
+func (p *parser) parseWindowSpec() {
+	p.expectOp("(")
+	if p.isIdent(p.peek()) && !p.isWord(p.peek(), "PARTITION", "ROWS", "RANGE", "GROUPS") {
+		p.parseIdent()
+	}
+	if p.acceptWords("PARTITION", "BY") {
+		p.parseExprList()
+	}
+	if p.acceptWords("ORDER", "BY") {
+		p.parseOrderBy()
+	}
+	p.skipUntil() // frame
+	p.expectOp(")")
+}
+
+// parseCase parses "CASE [foo] WHEN ... THEN ... [ELSE ...] END".
+func (p *parser) parseCase() Expr {
+	var e = &CaseExpr{Offset: p.expectWord("CASE").offset}
+	if !p.isWord(p.peek(), "WHEN") {
+		e.Operand = p.parseExpr()
+	}
+	for p.acceptWord("WHEN") {
+		var when = &When{Cond: p.parseExpr()}
+		p.expectWord("THEN")
+		when.Result = p.parseExpr()
+		e.Whens = append(e.Whens, when)
+	}
+	if len(e.Whens) == 0 {
+		p.fail("expected WHEN, found %s", p.peek())
+	}
+	if p.acceptWord("ELSE") {
+		e.Else = p.parseExpr()
+	}
+	p.expectWord("END")
+	return e
+}
+
+//------------------------------------------------------------------------------
+
+// PlaceholderStyle returns the style of a placeholder: "?", "?N", "$N", ":name", "@name" or "$name".
+func PlaceholderStyle(text string) string {
+	if len(text) <= 1 {
+		return text
+	}
+	var isNumber = true
+	for i := 1; i < len(text); i++ {
+		if !isDigit(text[i]) {
+			isNumber = false
+		}
+	}
+	if isNumber {
+		return text[:1] + "N"
+	}
+	return text[:1] + "name"
+}
+
+//------------------------------------------------------------------------------
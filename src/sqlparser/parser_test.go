@@ -0,0 +1,173 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func mustGetDialect(t *testing.T, name string) *Dialect {
+	t.Helper()
+	var dialect, err = GetDialect(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dialect
+}
+
+//------------------------------------------------------------------------------
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		dialect string
+		query   string
+		kinds   []string // of the statements, nil if the query is invalid
+		errText string   // part of the error message if the query is invalid
+	}{
+		// common syntax
+		{"mysql", "SELECT id, name FROM users WHERE id = ?", []string{"SELECT"}, ""},
+		{"postgres", "SELECT u.id FROM users u JOIN orders o ON o.user_id = u.id WHERE o.total > 10 ORDER BY u.id LIMIT 5", []string{"SELECT"}, ""},
+		{"sqlite", "SELECT COUNT(*) FROM users GROUP BY name HAVING COUNT(*) > 1", []string{"SELECT"}, ""},
+		{"mysql", "INSERT INTO users (id, name) VALUES (?, ?)", []string{"INSERT"}, ""},
+		{"postgres", "UPDATE users SET name = $1 WHERE id = $2", []string{"UPDATE"}, ""},
+		{"sqlite", "DELETE FROM users WHERE id IN (SELECT user_id FROM bans)", []string{"DELETE"}, ""},
+		{"mysql", "CREATE TABLE users (id INT PRIMARY KEY, name VARCHAR(64) NOT NULL)", []string{"CREATE TABLE"}, ""},
+		{"postgres", "DROP TABLE users", []string{"DROP TABLE"}, ""},
+		{"sqlite", "BEGIN; SELECT 1; COMMIT", []string{"BEGIN", "SELECT", "COMMIT"}, ""},
+		{"mysql", "SELECT CASE WHEN id > 1 THEN 'a' ELSE 'b' END FROM users", []string{"SELECT"}, ""},
+
+		// dialect-specific syntax
+		{"mysql", "SELECT `id` FROM `users` WHERE name = 'it\\'s'", []string{"SELECT"}, ""},
+		{"mysql", "SELECT id FROM users # comment", []string{"SELECT"}, ""},
+		{"postgres", "SELECT id::text FROM users WHERE name ILIKE $1", []string{"SELECT"}, ""},
+		{"postgres", "SELECT $$it's$$, E'a\\n' FROM users", []string{"SELECT"}, ""},
+		{"postgres", "INSERT INTO users (name) VALUES ($1) RETURNING id", []string{"INSERT"}, ""},
+		{"sqlite", "SELECT [id] FROM users WHERE name = :name OR name = @name OR id = ?1", []string{"SELECT"}, ""},
+
+		// invalid queries
+		{"mysql", "SELEC id FROM users", nil, "unknown statement"},
+		{"postgres", "SELECT id FROM", nil, ""},
+		{"sqlite", "SELECT id FROM users WHERE", nil, ""},
+		{"mysql", "SELECT id, FROM users", nil, ""},
+		{"postgres", "INSERT INTO users (id VALUES ($1)", nil, ""},
+		{"sqlite", "SELECT 'unterminated FROM users", nil, ""},
+		{"mysql", "SELECT id FROM users WHERE id = 1 1", nil, ""},
+		{"postgres", "SELECT `id` FROM users", nil, ""},  // backquotes are mysql and sqlite only
+		{"mysql", "SELECT id::text FROM users", nil, ""}, // "::" is postgres only
+	}
+
+	for _, test := range tests {
+		var stmts, err = Parse(test.query, mustGetDialect(t, test.dialect))
+		if test.kinds == nil {
+			if err == nil {
+				t.Errorf("%s: %q: expected an error", test.dialect, test.query)
+			} else if _, ok := err.(*Error); !ok {
+				t.Errorf("%s: %q: expected an *Error, got %T", test.dialect, test.query, err)
+			} else if !strings.Contains(err.Error(), test.errText) {
+				t.Errorf("%s: %q: expected an error containing %q, got %q", test.dialect, test.query, test.errText, err.Error())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %q: unexpected error: %s", test.dialect, test.query, err.Error())
+			continue
+		}
+		var kinds []string
+		for _, stmt := range stmts {
+			kinds = append(kinds, StatementKind(stmt))
+		}
+		if strings.Join(kinds, ",") != strings.Join(test.kinds, ",") {
+			t.Errorf("%s: %q: expected the statements %v, got %v", test.dialect, test.query, test.kinds, kinds)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestPlaceholders(t *testing.T) {
+	var tests = []struct {
+		dialect       string
+		query         string
+		placeholders  []string
+		argumentCount int
+	}{
+		{"mysql", "SELECT id FROM users WHERE id = ? AND name = ?", []string{"?", "?"}, 2},
+		{"mysql", "SELECT '?' FROM users WHERE id = ?", []string{"?"}, 1}, // not in a string
+		{"mysql", "SET @a = 1; SELECT @a FROM users", nil, 0},             // variables, not placeholders
+		{"postgres", "SELECT id FROM users WHERE id = $2 OR id = $1", []string{"$2", "$1"}, 2},
+		{"postgres", "SELECT id FROM users WHERE id = $1 OR parent = $1", []string{"$1", "$1"}, 1},
+		{"postgres", "SELECT $$ $1 $$ FROM users", nil, 0}, // in a dollar-quoted string
+		{"sqlite", "SELECT id FROM users WHERE id = ?1 AND name = ?2", []string{"?1", "?2"}, 2},
+		{"sqlite", "SELECT id FROM users WHERE name = :name", []string{":name"}, -1},           // named
+		{"sqlite", "SELECT id FROM users WHERE id = ? AND name = ?2", []string{"?", "?2"}, -1}, // mixed
+	}
+
+	for _, test := range tests {
+		var placeholders, err = Placeholders(test.query, mustGetDialect(t, test.dialect))
+		if err != nil {
+			t.Errorf("%s: %q: unexpected error: %s", test.dialect, test.query, err.Error())
+			continue
+		}
+		var texts []string
+		for _, placeholder := range placeholders {
+			texts = append(texts, placeholder.Text)
+		}
+		if strings.Join(texts, ",") != strings.Join(test.placeholders, ",") {
+			t.Errorf("%s: %q: expected the placeholders %v, got %v", test.dialect, test.query, test.placeholders, texts)
+		}
+		if count := ArgumentCount(placeholders); count != test.argumentCount {
+			t.Errorf("%s: %q: expected %d argument(s), got %d", test.dialect, test.query, test.argumentCount, count)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestSplitStatements(t *testing.T) {
+	var tests = []struct {
+		dialect string
+		text    string
+		stmts   []string
+	}{
+		{"mysql", "SELECT 1; SELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"mysql", "INSERT INTO t VALUES ('a;b'); -- c;d\nSELECT 2", []string{"INSERT INTO t VALUES ('a;b')", "SELECT 2"}},
+		{"postgres", "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\n;\nSELECT 3",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT 3"}},
+		{"sqlite", "/* a; b */ SELECT [x;y] FROM t", []string{"SELECT [x;y] FROM t"}},
+		{"sqlite", " ; ", nil},
+	}
+
+	for _, test := range tests {
+		var stmts, err = SplitStatements(test.text, mustGetDialect(t, test.dialect))
+		if err != nil {
+			t.Errorf("%s: %q: unexpected error: %s", test.dialect, test.text, err.Error())
+			continue
+		}
+		var texts []string
+		for _, stmt := range stmts {
+			texts = append(texts, strings.TrimSpace(stmt.Text))
+			if test.text[stmt.Offset:stmt.Offset+len(stmt.Text)] != stmt.Text {
+				t.Errorf("%s: %q: wrong offset %d for %q", test.dialect, test.text, stmt.Offset, stmt.Text)
+			}
+		}
+		if strings.Join(texts, "|") != strings.Join(test.stmts, "|") {
+			t.Errorf("%s: %q: expected the statements %q, got %q", test.dialect, test.text, test.stmts, texts)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestGetDialect(t *testing.T) {
+	for _, name := range append([]string{"MySQL", "Postgres"}, DialectNames...) {
+		if dialect, err := GetDialect(name); err != nil || dialect.Name != strings.ToLower(name) {
+			t.Errorf("GetDialect(%q): unexpected result %v, %v", name, dialect, err)
+		}
+	}
+	if _, err := GetDialect("oracle"); err == nil {
+		t.Errorf("GetDialect(\"oracle\"): expected an error")
+	}
+}
+
+//------------------------------------------------------------------------------
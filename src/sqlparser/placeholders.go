@@ -0,0 +1,70 @@
+package sqlparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Placeholders returns the bind parameters of a query which are supported by the dialect, in order
+// (the variables of mysql, e.g. "@foo", are not bind parameters).
+func Placeholders(query string, dialect *Dialect) ([]*Placeholder, error) {
+	var tokens, err = tokenize(query, dialect)
+	if err != nil {
+		return nil, err
+	}
+	var placeholders []*Placeholder
+	for _, t := range tokens {
+		if t.kind != tokPlaceholder || (strings.HasPrefix(t.text, "@") && dialect.Name == "mysql") {
+			continue
+		}
+		if dialect.placeholders[PlaceholderStyle(t.text)] {
+			placeholders = append(placeholders, &Placeholder{Offset: t.offset, Text: t.text})
+		}
+	}
+	return placeholders, nil
+}
+
+// ArgumentCount returns the number of arguments expected by these placeholders: the number of "?",
+// or the highest N of "$N" or "?N", or -1 if it cannot be known (named or mixed placeholders).
+func ArgumentCount(placeholders []*Placeholder) int {
+	var anonymous, highest = 0, 0
+	for _, placeholder := range placeholders {
+		switch PlaceholderStyle(placeholder.Text) {
+		case "?":
+			anonymous++
+		case "$N", "?N":
+			var n, _ = strconv.Atoi(placeholder.Text[1:])
+			if n > highest {
+				highest = n
+			}
+		default:
+			return -1
+		}
+	}
+	if anonymous > 0 && highest > 0 {
+		return -1
+	}
+	return anonymous + highest
+}
+
+// NormalizePlaceholders replaces each placeholder of a query by "0" followed by spaces (so that
+// the offsets in the query are unchanged), so that linters which do not know the placeholders
+// of the dialect accept the query. The query is returned unchanged if it cannot be tokenized.
+func NormalizePlaceholders(query string, dialect *Dialect) string {
+	var placeholders, err = Placeholders(query, dialect)
+	if err != nil || len(placeholders) == 0 {
+		return query
+	}
+	var buf = []byte(query)
+	for _, placeholder := range placeholders {
+		buf[placeholder.Offset] = '0'
+		for i := 1; i < len(placeholder.Text); i++ {
+			buf[placeholder.Offset+i] = ' '
+		}
+	}
+	return string(buf)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,117 @@
+package sqlparser
+
+import (
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// ResultColumn is a column of the rows returned by a statement.
+type ResultColumn struct {
+	Name string // "" if named by the database, e.g. for "COUNT(*)"
+	Type string // upper case, e.g. "VARCHAR(255)", "" if unknown
+}
+
+//------------------------------------------------------------------------------
+
+// ResultColumns returns the columns of the rows returned by a SELECT, or by an INSERT, an UPDATE or a DELETE
+// with RETURNING, or ok=false if they cannot be known (e.g. "SELECT *" without the schema, which may be nil)
+// or if the statement returns no rows.
+func ResultColumns(stmt Statement, schema *Schema) (columns []ResultColumn, ok bool) {
+	switch stmt := stmt.(type) {
+	case *SelectStmt:
+		return resultColumns(stmt.Columns, flattenTableRefs(stmt.From), schema)
+	case *InsertStmt:
+		if stmt.Returning != nil {
+			return resultColumns(stmt.Returning, []*TableRef{stmt.Table}, schema)
+		}
+	case *UpdateStmt:
+		if stmt.Returning != nil {
+			return resultColumns(stmt.Returning, flattenTableRefs(append(append([]*TableRef{}, stmt.Tables...), stmt.From...)), schema)
+		}
+	case *DeleteStmt:
+		if stmt.Returning != nil {
+			return resultColumns(stmt.Returning, flattenTableRefs(append(append([]*TableRef{}, stmt.Tables...), stmt.Using...)), schema)
+		}
+	}
+	return nil, false
+}
+
+// resultColumns returns the columns of a SELECT or of a RETURNING clause, whose tables are refs.
+func resultColumns(selectColumns []*SelectColumn, refs []*TableRef, schema *Schema) (columns []ResultColumn, ok bool) {
+	for _, column := range selectColumns {
+		if column.Star {
+			for _, ref := range refs {
+				if column.StarTable != "" && !isTableRefNamed(ref, column.StarTable) {
+					continue
+				}
+				var table = resultTable(ref, schema)
+				if table == nil || table.Columns == nil {
+					return nil, false
+				}
+				for _, name := range table.Columns {
+					columns = append(columns, ResultColumn{Name: name, Type: table.ColumnType(name)})
+				}
+			}
+			continue
+		}
+		var result = ResultColumn{Name: column.Alias, Type: resultExprType(column.Expr, refs, schema)}
+		if columnRef, isColumnRef := column.Expr.(*ColumnRef); isColumnRef && result.Name == "" {
+			result.Name = columnRef.Name
+		}
+		columns = append(columns, result)
+	}
+	return columns, true
+}
+
+// resultTable returns the table of the schema of a table of a FROM clause, or nil if unknown (e.g. a subquery).
+func resultTable(ref *TableRef, schema *Schema) *TableSchema {
+	if schema == nil || ref.Name == "" {
+		return nil
+	}
+	return schema.Table(ref.Name)
+}
+
+func isTableRefNamed(ref *TableRef, name string) bool {
+	return strings.EqualFold(ref.Alias, name) || (ref.Alias == "" && strings.EqualFold(ref.Name, name))
+}
+
+// resultExprType returns the type of the value of an expression, or "" if unknown.
+func resultExprType(e Expr, refs []*TableRef, schema *Schema) string {
+	switch e := e.(type) {
+	case *ColumnRef:
+		for _, ref := range refs {
+			if e.Table != "" && !isTableRefNamed(ref, e.Table) {
+				continue
+			}
+			if table := resultTable(ref, schema); table != nil && table.Columns != nil && table.columnIndex(e.Name) != -1 {
+				return table.ColumnType(e.Name)
+			}
+		}
+	case *CastExpr:
+		return e.Type
+	case *FuncCall:
+		switch e.Name {
+		case "COUNT":
+			return "BIGINT"
+		case "MIN", "MAX", "COALESCE", "IFNULL":
+			if len(e.Args) > 0 {
+				return resultExprType(e.Args[0], refs, schema)
+			}
+		case "NOW", "CURRENT_TIMESTAMP":
+			return "TIMESTAMP"
+		case "CONCAT", "LOWER", "UPPER", "TRIM", "SUBSTRING", "SUBSTR", "REPLACE":
+			return "TEXT"
+		}
+	case *Literal:
+		switch e.Kind {
+		case "string":
+			return "TEXT"
+		case "bool":
+			return "BOOLEAN"
+		}
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
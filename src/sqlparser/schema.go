@@ -0,0 +1,177 @@
+package sqlparser
+
+import (
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Schema is the list of the tables of a database and of their columns, built from
+// CREATE TABLE/VIEW, ALTER TABLE and DROP statements (e.g. migration files or a dump).
+type Schema struct {
+	tables map[string]*TableSchema // by lower case name
+}
+
+// TableSchema is a table or a view of a Schema.
+type TableSchema struct {
+	Name    string
+	Columns []string // in order of declaration, nil if unknown (e.g. a view with "SELECT *")
+	Types   []string // types of the columns, upper case (e.g. "VARCHAR(255)"), "" if unknown
+}
+
+//------------------------------------------------------------------------------
+
+// NewSchema returns an empty schema.
+func NewSchema() *Schema {
+	return &Schema{tables: make(map[string]*TableSchema)}
+}
+
+// Table returns the table or the view with this name (case-insensitive), or nil.
+func (s *Schema) Table(name string) *TableSchema {
+	return s.tables[strings.ToLower(name)]
+}
+
+// TableCount returns the number of tables and views.
+func (s *Schema) TableCount() int {
+	return len(s.tables)
+}
+
+// HasColumn returns true if the table has this column (case-insensitive), or if the columns are unknown.
+func (t *TableSchema) HasColumn(name string) bool {
+	if t.Columns == nil {
+		return true
+	}
+	return t.columnIndex(name) != -1
+}
+
+// ColumnType returns the type of a column (e.g. "VARCHAR(255)"), or "" if unknown.
+func (t *TableSchema) ColumnType(name string) string {
+	if i := t.columnIndex(name); i != -1 && i < len(t.Types) {
+		return t.Types[i]
+	}
+	return ""
+}
+
+func (t *TableSchema) columnIndex(name string) int {
+	for i, column := range t.Columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+//------------------------------------------------------------------------------
+
+// Apply updates the schema with a statement; statements other than CREATE TABLE/VIEW, ALTER TABLE
+// and DROP TABLE/VIEW are ignored.
+func (s *Schema) Apply(stmt Statement) {
+	switch stmt := stmt.(type) {
+
+	case *CreateTableStmt:
+		if stmt.IfNotExists && s.Table(stmt.Table.Name) != nil {
+			return
+		}
+		var table = &TableSchema{Name: stmt.Table.Name, Columns: []string{}}
+		if stmt.AsSelect != nil {
+			table.Columns = s.selectColumnNames(stmt.AsSelect)
+			table.Types = make([]string, len(table.Columns))
+		}
+		for _, def := range stmt.Columns {
+			table.Columns = append(table.Columns, def.Name)
+			table.Types = append(table.Types, def.Type)
+		}
+		s.tables[strings.ToLower(table.Name)] = table
+
+	case *CreateViewStmt:
+		var view = &TableSchema{Name: stmt.View.Name, Columns: stmt.Columns}
+		if view.Columns == nil {
+			view.Columns = s.selectColumnNames(stmt.Select)
+		}
+		view.Types = make([]string, len(view.Columns))
+		s.tables[strings.ToLower(view.Name)] = view
+
+	case *AlterTableStmt:
+		var table = s.Table(stmt.Table.Name)
+		if table == nil {
+			return
+		}
+		if table.Columns != nil {
+			for _, def := range stmt.AddColumns {
+				table.Columns = append(table.Columns, def.Name)
+				table.Types = append(table.Types, def.Type)
+			}
+			for _, name := range stmt.DropColumns {
+				if i := table.columnIndex(name); i != -1 {
+					table.Columns = append(table.Columns[:i:i], table.Columns[i+1:]...)
+					table.Types = append(table.Types[:i:i], table.Types[i+1:]...)
+				}
+			}
+			for oldName, newName := range stmt.RenameColumns {
+				if i := table.columnIndex(oldName); i != -1 {
+					table.Columns[i] = newName
+				}
+			}
+		}
+		if stmt.RenameTo != "" {
+			delete(s.tables, strings.ToLower(table.Name))
+			table.Name = stmt.RenameTo
+			s.tables[strings.ToLower(table.Name)] = table
+		}
+
+	case *DropStmt:
+		if stmt.Kind == "TABLE" || strings.HasSuffix(stmt.Kind, "VIEW") {
+			for _, name := range stmt.Names {
+				delete(s.tables, strings.ToLower(name))
+			}
+		}
+	}
+}
+
+// selectColumnNames returns the names of the columns of a SELECT, or nil if they cannot be known.
+func (s *Schema) selectColumnNames(stmt *SelectStmt) (names []string) {
+	for _, column := range stmt.Columns {
+		switch {
+		case column.Alias != "":
+			names = append(names, column.Alias)
+		case column.Star:
+			var tables = stmt.From
+			if column.StarTable != "" {
+				tables = nil
+				for _, ref := range flattenTableRefs(stmt.From) {
+					if strings.EqualFold(ref.Alias, column.StarTable) || (ref.Alias == "" && strings.EqualFold(ref.Name, column.StarTable)) {
+						tables = append(tables, ref)
+					}
+				}
+			}
+			for _, ref := range flattenTableRefs(tables) {
+				var table = s.Table(ref.Name)
+				if ref.Name == "" || table == nil || table.Columns == nil {
+					return nil
+				}
+				names = append(names, table.Columns...)
+			}
+		default:
+			var columnRef, ok = column.Expr.(*ColumnRef)
+			if !ok {
+				return nil // e.g. "SELECT COUNT(*)" without alias
+			}
+			names = append(names, columnRef.Name)
+		}
+	}
+	return names
+}
+
+// flattenTableRefs returns the tables of a FROM clause, including those of the parenthesized joins.
+func flattenTableRefs(refs []*TableRef) (flat []*TableRef) {
+	for _, ref := range refs {
+		if ref.Nested != nil {
+			flat = append(flat, flattenTableRefs(ref.Nested)...)
+		} else {
+			flat = append(flat, ref)
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
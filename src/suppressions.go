@@ -0,0 +1,202 @@
+package src
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+	"github.com/phrounz/go-parano/src/util"
+)
+
+//------------------------------------------------------------------------------
+
+const constIgnoreDirective = "IGNORE"
+
+// identifiers of the checks, to be used with //!PARANO__IGNORE <check-id>
+const constCheckIDPrivateToFile = "private-to-file"
+const constCheckIDExhaustiveFilling = "exhaustive-filling"
+const constCheckIDUnused = "unused"
+const constCheckIDDirective = "directive"
+const constCheckIDImmutable = "immutable"
+const constCheckIDSQLQuery = "sql-query"
+const constCheckIDSQLInjection = "sql-injection"
+const constCheckIDSQLScan = "sql-scan"
+const constCheckIDEmbeddedLang = "embedded-lang"
+const constCheckIDMustCheckError = "must-check-error"
+const constCheckIDMustUse = "must-use"
+const constCheckIDDeprecated = "deprecated"
+const constCheckIDGuardedBy = "guarded-by"
+
+var knownCheckIDs = []string{constCheckIDPrivateToFile, constCheckIDExhaustiveFilling, constCheckIDUnused, constCheckIDDirective,
+	constCheckIDImmutable, constCheckIDSQLQuery, constCheckIDSQLInjection, constCheckIDSQLScan, constCheckIDEmbeddedLang,
+	constCheckIDMustCheckError, constCheckIDMustUse, constCheckIDDeprecated, constCheckIDGuardedBy}
+
+func init() {
+	knownCheckIDs = append(knownCheckIDs, getSQLPolicyRuleCheckIDs()...) // e.g. "sql-select-star"
+}
+
+//------------------------------------------------------------------------------
+
+// position is where a problem has been found, offset and line being -1 if unknown.
+type position struct {
+	filename string
+	offset   int
+	line     int
+}
+
+func nodePosition(filename string, n *fileparser.Node) position {
+	return position{filename: filename, offset: n.BytesIndexBegin, line: n.Line}
+}
+
+func commentPosition(filename string, comment fileparser.Comment) position {
+	return position{filename: filename, offset: comment.BytesIndexBegin, line: comment.Line}
+}
+
+//------------------------------------------------------------------------------
+
+type suppression struct {
+	checkID string
+	reason  string
+	until   time.Time // zero if no expiry
+	comment string
+	pos     position
+	target  directiveTarget
+	used    bool
+}
+
+var suppressionsByFile = make(map[string][]*suppression)
+
+//------------------------------------------------------------------------------
+
+// notPass reports a problem found by a check, unless it is suppressed with //!PARANO__IGNORE.
+func notPass(checkID string, pos position, message string, args ...interface{}) {
+	var s, expired = findSuppression(checkID, pos)
+	if s != nil && !expired {
+		if util.IsInfo() {
+			util.Info("  Ignoring (%s): %s", s.reason, fmt.Sprintf(message, args...))
+		}
+		return
+	}
+	if s != nil {
+		util.NotPass("%s\n(%s in %s:%d expired on %s)", fmt.Sprintf(message, args...), s.comment, s.pos.filename, s.pos.line, s.until.Format("2006-01-02"))
+		return
+	}
+	util.NotPass(message, args...)
+}
+
+//------------------------------------------------------------------------------
+
+// isSuppressed returns true if a problem of this check found at this position is suppressed, e.g. for a
+// problem reported as a warning rather than with notPass.
+func isSuppressed(checkID string, pos position) bool {
+	var s, expired = findSuppression(checkID, pos)
+	return s != nil && !expired
+}
+
+//------------------------------------------------------------------------------
+
+// findSuppression returns the suppression matching this check at this position (marking it as used), or nil.
+func findSuppression(checkID string, pos position) (found *suppression, expired bool) {
+	if pos.offset < 0 {
+		return
+	}
+	for _, s := range suppressionsByFile[pos.filename] {
+		if s.checkID != checkID {
+			continue
+		}
+		var matches bool
+		if s.target.node == nil {
+			matches = (s.target.line == pos.line)
+		} else {
+			matches = (pos.offset >= s.target.node.BytesIndexBegin && pos.offset < s.target.node.BytesIndexEnd)
+		}
+		if matches {
+			s.used = true
+			found = s
+			expired = !s.until.IsZero() && time.Now().After(s.until.AddDate(0, 0, 1))
+			if !expired {
+				return
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// registerSuppressions reads the //!PARANO__IGNORE directives of a file.
+func registerSuppressions(filename string, fileInfos infosFile) {
+
+	var targets = directiveTargets(fileInfos.rootNode, fileInfos.comments)
+
+	for _, comment := range fileInfos.comments {
+		var d, ok = fileparser.ParseDirective(comment.Text)
+		if !ok || d.Name != constIgnoreDirective {
+			continue
+		}
+		var pos = commentPosition(filename, comment)
+		var target, attached = targets[comment.BytesIndexBegin]
+		if !attached {
+			continue // reported by ParanoDirectivesCheck if needed
+		}
+		if len(d.Args) != 1 {
+			util.NotPass("%s in %s:%d shall have exactly one check identifier among: %s",
+				comment.Text, filename, comment.Line, strings.Join(knownCheckIDs, ", "))
+			continue
+		}
+		if !isKnownCheckID(d.Args[0]) {
+			util.NotPass("%s in %s:%d: unknown check identifier %s (expected one of: %s)",
+				comment.Text, filename, comment.Line, d.Args[0], strings.Join(knownCheckIDs, ", "))
+			continue
+		}
+		var s = &suppression{
+			checkID: d.Args[0],
+			reason:  d.Options["reason"],
+			comment: comment.Text,
+			pos:     pos,
+			target:  target,
+		}
+		if until, ok := d.Options["until"]; ok {
+			var err error
+			s.until, err = time.Parse("2006-01-02", until)
+			if err != nil {
+				util.NotPass("%s in %s:%d: invalid date until=%s, expected YYYY-MM-DD", comment.Text, filename, comment.Line, until)
+				continue
+			}
+		}
+		suppressionsByFile[filename] = append(suppressionsByFile[filename], s)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReportUnusedSuppressions reports the //!PARANO__IGNORE directives which did not match any problem.
+func ReportUnusedSuppressions() {
+	var filenames = make([]string, 0, len(suppressionsByFile))
+	for filename := range suppressionsByFile {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		for _, s := range suppressionsByFile[filename] {
+			if !s.used {
+				util.NotPass("%s in %s:%d does not match any problem", s.comment, filename, s.pos.line)
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func isKnownCheckID(checkID string) bool {
+	for _, knownCheckID := range knownCheckIDs {
+		if knownCheckID == checkID {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,161 @@
+package src
+
+import (
+	"github.com/phrounz/go-parano/src/fileparser"
+)
+
+//------------------------------------------------------------------------------
+
+// symbolIndex maps each name of a package to its declaration(s) and annotations,
+// so that checking a reference to a name is a single lookup.
+// Methods are stored as "Type.Method".
+type symbolIndex struct {
+	symbols map[string][]*symbolInfo
+}
+
+type symbolInfo struct {
+	name                    string
+	filename                string
+	kind                    string           // "func", "method", "type", "var", "const", or "" if only known through an annotation
+	declNode                *fileparser.Node // FuncDecl, TypeSpec or ValueSpec, nil if unknown
+	privateToFile           bool
+	exhaustiveFillingFields map[string]bool // nil if not declared with //!PARANO__EXHAUSTIVE_FILLING
+	immutable               bool
+	mustCheckError          bool
+	mustUse                 bool
+	deprecation             *deprecation   // nil if not deprecated
+	guardedBy               *lockDirective // nil if not declared with //!PARANO__GUARDED_BY
+	requiresLock            *lockDirective // nil if not declared with //!PARANO__REQUIRES_LOCK
+}
+
+//------------------------------------------------------------------------------
+
+func newSymbolIndex(infosByFile map[string]infosFile) *symbolIndex {
+
+	var si = &symbolIndex{symbols: make(map[string][]*symbolInfo)}
+
+	for filename, fileInfos := range infosByFile {
+
+		//----
+		// top-level declarations
+
+		for _, nFile := range fileInfos.rootNode.Children {
+			if nFile.TypeStr != "File" {
+				continue
+			}
+			for _, n := range nFile.Children {
+				switch n.TypeStr {
+				case "FuncDecl":
+					if n.IsMethod() {
+						si.addDecl(n.ReceiverTypeName()+"."+n.Name, filename, "method", n)
+					} else {
+						si.addDecl(n.Name, filename, "func", n)
+					}
+				case "TypeSpec":
+					si.addDecl(n.Name, filename, "type", n)
+				case "ValueSpec": // const declarations are flattened by the file parser
+					for _, name := range n.DeclaredNames() {
+						si.addDecl(name, filename, "const", n)
+					}
+				case "GenDecl": // var declarations
+					for _, n2 := range n.Children {
+						if n2.TypeStr == "ValueSpec" {
+							for _, name := range n2.DeclaredNames() {
+								si.addDecl(name, filename, "var", n2)
+							}
+						}
+					}
+				}
+			}
+		}
+
+		//----
+		// annotations
+
+		for name := range fileInfos.featurePrivateToFile.privateToFileDecl {
+			si.get(name, filename).privateToFile = true
+		}
+		for name, fields := range fileInfos.featureExhaustiveFilling.exhaustiveFillingStructs {
+			si.get(name, filename).exhaustiveFillingFields = fields
+		}
+		for name := range fileInfos.featureImmutable.immutableDecl {
+			si.get(name, filename).immutable = true
+		}
+		for name := range fileInfos.featureMustCheckError.mustCheckErrorDecl {
+			si.get(name, filename).mustCheckError = true
+		}
+		for name := range fileInfos.featureMustUse.mustUseDecl {
+			si.get(name, filename).mustUse = true
+		}
+		for name, d := range fileInfos.featureDeprecated.deprecatedDecl {
+			si.get(name, filename).deprecation = d
+		}
+		for name, d := range fileInfos.featureGuardedBy.guardedByDecl {
+			si.get(name, filename).guardedBy = d
+		}
+		for name, d := range fileInfos.featureGuardedBy.requiresLockDecl {
+			si.get(name, filename).requiresLock = d
+		}
+	}
+
+	return si
+}
+
+//------------------------------------------------------------------------------
+
+// lookup returns the symbols of the package with this name, or nil.
+func (si *symbolIndex) lookup(name string) []*symbolInfo {
+	return si.symbols[name]
+}
+
+//------------------------------------------------------------------------------
+
+// lookupDecl returns the declaration of this kind with this name, or nil.
+func (si *symbolIndex) lookupDecl(name string, kind string) *symbolInfo {
+	for _, symbol := range si.symbols[name] {
+		if symbol.kind == kind {
+			return symbol
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// visit calls fnCall on each symbol of the package.
+func (si *symbolIndex) visit(fnCall func(*symbolInfo)) {
+	for _, symbols := range si.symbols {
+		for _, symbol := range symbols {
+			fnCall(symbol)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (si *symbolIndex) addDecl(name string, filename string, kind string, declNode *fileparser.Node) {
+	if name == "_" {
+		return
+	}
+	var symbol = si.get(name, filename)
+	if symbol.declNode == nil {
+		symbol.kind = kind
+		symbol.declNode = declNode
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// get returns the symbol with this name declared in this file, creating it if needed.
+func (si *symbolIndex) get(name string, filename string) *symbolInfo {
+	for _, symbol := range si.symbols[name] {
+		if symbol.filename == filename {
+			return symbol
+		}
+	}
+	var symbol = &symbolInfo{name: name, filename: filename}
+	si.symbols[name] = append(si.symbols[name], symbol)
+	return symbol
+}
+
+//------------------------------------------------------------------------------
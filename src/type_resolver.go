@@ -0,0 +1,682 @@
+package src
+
+import (
+	"strings"
+
+	"github.com/phrounz/go-parano/src/fileparser"
+)
+
+//------------------------------------------------------------------------------
+
+// goType is a type found from the source code, without type checking, e.g. "*sql.DB" in a file
+// importing "database/sql" gives {pkgPath: "database/sql", pkgName: "sql", name: "DB", pointer: true}.
+// For the types declared in the scanned packages, pkgPath is the package name.
+// For builtin types (int, string, error ...) and types written as is (e.g. "[]byte"), pkgPath is empty.
+type goType struct {
+	pkgPath string
+	pkgName string
+	name    string
+	pointer bool
+}
+
+func (t goType) String() string {
+	var str = t.name
+	if t.pkgPath != "" {
+		str = t.pkgPath + "." + str
+	}
+	if t.pointer {
+		str = "*" + str
+	}
+	return str
+}
+
+// parseGoTypeString parses a type written like "*database/sql.DB".
+func parseGoTypeString(str string) (t goType) {
+	if strings.HasPrefix(str, "*") {
+		t.pointer = true
+		str = str[1:]
+	}
+	var index = strings.LastIndex(str, ".")
+	if index == -1 {
+		t.name = str
+	} else {
+		t.pkgPath = str[:index]
+		t.pkgName = fileparser.DefaultPackageName(t.pkgPath)
+		t.name = str[index+1:]
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// builtin types which may be used without declaration
+var builtinTypes = map[string]bool{
+	"bool": true, "byte": true, "complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"uintptr": true, "any": true,
+}
+
+const constMaxTypeResolverDepth = 16
+
+//------------------------------------------------------------------------------
+
+// typeResolver finds the types of the expressions of a file, following the declarations in the scanned
+// packages and a few well-known functions of the standard and database libraries (see
+// getKnownResultTypes()). This is not a type checker: it gives up (ok=false) on anything complicated.
+type typeResolver struct {
+	mInfosByPackageName map[string]*packageInfos
+	pkg                 *packageInfos
+	filename            string
+	imports             map[string]string
+	depth               int
+}
+
+func newTypeResolver(mInfosByPackageName map[string]*packageInfos, pkg *packageInfos, filename string) *typeResolver {
+	return &typeResolver{
+		mInfosByPackageName: mInfosByPackageName,
+		pkg:                 pkg,
+		filename:            filename,
+		imports:             pkg.infosByFile[filename].imports,
+	}
+}
+
+// forFile returns a resolver for another file, e.g. where a symbol is declared.
+func (tr *typeResolver) forFile(pkg *packageInfos, filename string) *typeResolver {
+	var tr2 = newTypeResolver(tr.mInfosByPackageName, pkg, filename)
+	tr2.depth = tr.depth + 1
+	return tr2
+}
+
+//------------------------------------------------------------------------------
+
+// exprType returns the type of an expression.
+func (tr *typeResolver) exprType(n *fileparser.Node) (t goType, ok bool) {
+
+	if tr.depth > constMaxTypeResolverDepth {
+		return
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	switch n.TypeStr {
+
+	case "Ident": // foo
+		return tr.identType(n)
+
+	case "SelectorExpr": // foo.bar
+		var nX = n.Children[0]
+		var selName = n.Children[1].Name
+		if importPath, isImport := tr.getImportPath(nX); isImport { // pkg.Var
+			if pkg := tr.findScannedPackage(importPath); pkg != nil {
+				if symbol := pkg.symbols.lookupDecl(selName, "var"); symbol != nil {
+					return tr.forFile(pkg, symbol.filename).valueSpecType(symbol.declNode, selName)
+				}
+			}
+			return
+		}
+		if xType, ok2 := tr.exprType(nX); ok2 { // variable.field
+			return tr.fieldType(xType, selName)
+		}
+
+	case "CallExpr": // foo(...)
+		var types = tr.callResultTypes(n)
+		if len(types) > 0 {
+			return types[0], true
+		}
+
+	case "UnaryExpr": // &foo
+		if n.Operator() == "&" && len(n.Children) == 1 {
+			t, ok = tr.exprType(n.Children[0])
+			t.pointer = true
+		}
+
+	case "StarExpr": // *foo
+		t, ok = tr.exprType(n.Children[0])
+		t.pointer = false
+
+	case "ParenExpr": // (foo)
+		return tr.exprType(n.Children[0])
+
+	case "IndexExpr": // foo[i]
+		return tr.elementType(n.Children[0], false)
+
+	case "CompositeLit": // Foo{...}
+		return tr.compositeLitType(n)
+
+	case "TypeAssertExpr": // foo.(Bar)
+		if len(n.Children) == 2 {
+			return tr.typeExprType(n.Children[1])
+		}
+
+	case "BasicLit":
+		if strings.HasPrefix(n.Bytes, "\"") || strings.HasPrefix(n.Bytes, "`") {
+			return goType{name: "string"}, true
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// exprTypes returns the types of the values of an expression, e.g. two for "rows, err := db.Query(...)".
+func (tr *typeResolver) exprTypes(n *fileparser.Node) []goType {
+	if n.TypeStr == "CallExpr" {
+		return tr.callResultTypes(n)
+	}
+	if t, ok := tr.exprType(n); ok {
+		return []goType{t}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (tr *typeResolver) identType(n *fileparser.Node) (t goType, ok bool) {
+
+	var declNode = n.FindLocalDeclaration(n.Name)
+	if declNode == nil {
+		if symbol := tr.pkg.symbols.lookupDecl(n.Name, "var"); symbol != nil {
+			return tr.forFile(tr.pkg, symbol.filename).valueSpecType(symbol.declNode, n.Name)
+		}
+		return
+	}
+
+	switch declNode.TypeStr {
+	case "Field": // parameter
+		if nType := declNode.FieldType(); nType != nil {
+			return tr.typeExprType(nType)
+		}
+	case "ValueSpec": // var foo ...
+		return tr.valueSpecType(declNode, n.Name)
+	case "AssignStmt": // foo := ...
+		var lhs = declNode.AssignStmtLhs()
+		var rhs = declNode.AssignStmtRhs()
+		for i, nLhs := range lhs {
+			if nLhs.Name != n.Name {
+				continue
+			}
+			if len(rhs) == len(lhs) {
+				return tr.exprType(rhs[i])
+			} else if len(rhs) == 1 {
+				var types = tr.exprTypes(rhs[0])
+				if i < len(types) {
+					return types[i], true
+				}
+			}
+		}
+	case "RangeStmt": // for k, v := range foo
+		return tr.rangeVarType(declNode, n.Name)
+	}
+	return
+}
+
+// rangeVarType returns the type of a variable declared by a RangeStmt over a slice, an array or a map,
+// e.g. "w" in "for _, w := range ws" with "ws []io.Closer".
+func (tr *typeResolver) rangeVarType(rangeStmt *fileparser.Node, name string) (t goType, ok bool) {
+	var nX = rangeStmt.RangeStmtX()
+	if nX == nil {
+		return
+	}
+	return tr.elementType(nX, rangeStmt.DeclaredNames()[0] == name)
+}
+
+// elementType returns the type of the elements (or of the keys) of a slice, an array or a map expression,
+// whose type is declared with a type expression, e.g. "ws" declared with "ws []io.Closer" (see typeExprOf()),
+// or with a type declared as a slice, an array or a map.
+func (tr *typeResolver) elementType(n *fileparser.Node, key bool) (t goType, ok bool) {
+	var trType, nType = tr.typeExprOf(n)
+	if nType != nil && (nType.TypeStr == "Ident" || nType.TypeStr == "SelectorExpr") { // e.g. "type Writers []io.Closer"
+		if namedType, ok2 := trType.typeExprType(nType); ok2 && !namedType.pointer {
+			if pkg, typeSpec, filename := tr.findTypeSpec(namedType); typeSpec != nil && len(typeSpec.Children) > 0 {
+				trType, nType = tr.forFile(pkg, filename), typeSpec.Children[len(typeSpec.Children)-1]
+			}
+		}
+	}
+	if nType == nil || len(nType.Children) == 0 {
+		return
+	}
+	switch nType.TypeStr {
+	case "ArrayType", "Ellipsis": // []Foo, [n]Foo, ...Foo
+		if key {
+			return goType{name: "int"}, true
+		}
+		return trType.typeExprType(nType.Children[len(nType.Children)-1])
+	case "MapType": // map[Foo]Bar
+		if len(nType.Children) == 2 {
+			if key {
+				return trType.typeExprType(nType.Children[0])
+			}
+			return trType.typeExprType(nType.Children[1])
+		}
+	}
+	return
+}
+
+// typeExprOf returns the type expression of a variable or of a field of a struct, and the resolver of its
+// file, e.g. "[]io.Closer" for "ws" declared with "var ws []io.Closer", "ws := []io.Closer{...}" or
+// "ws := make([]io.Closer, n)", or for "s.ws" with a field "ws []io.Closer", or nil.
+func (tr *typeResolver) typeExprOf(n *fileparser.Node) (*typeResolver, *fileparser.Node) {
+	switch n.TypeStr {
+	case "ParenExpr":
+		return tr.typeExprOf(n.Children[0])
+	case "Ident":
+		var declNode = n.FindLocalDeclaration(n.Name)
+		if declNode == nil {
+			if symbol := tr.pkg.symbols.lookupDecl(n.Name, "var"); symbol != nil {
+				declNode, tr = symbol.declNode, tr.forFile(tr.pkg, symbol.filename)
+			} else {
+				return tr, nil
+			}
+		}
+		switch declNode.TypeStr {
+		case "Field":
+			return tr, declNode.FieldType()
+		case "ValueSpec":
+			if nType := declNode.ValueSpecType(); nType != nil {
+				return tr, nType
+			}
+		}
+		if nValue := getDeclaredValue(declNode, n.Name); nValue != nil {
+			if nValue.TypeStr == "CompositeLit" && len(nValue.Children) > len(nValue.CompositeLitElts()) {
+				return tr, nValue.Children[0]
+			}
+			if args := nValue.CallArgs(); nValue.TypeStr == "CallExpr" && nValue.Children[0].Bytes == "make" && len(args) > 0 {
+				return tr, args[0]
+			}
+		}
+	case "SelectorExpr":
+		if _, isImport := tr.getImportPath(n.Children[0]); isImport {
+			return tr, nil
+		}
+		if xType, ok := tr.exprType(n.Children[0]); ok {
+			if pkg, typeSpec, filename := tr.findTypeSpec(xType); typeSpec != nil {
+				for _, nField := range typeSpec.StructFields() {
+					for _, name := range nField.DeclaredNames() {
+						if name == n.Children[1].Name {
+							return tr.forFile(pkg, filename), nField.FieldType()
+						}
+					}
+				}
+			}
+		}
+	}
+	return tr, nil
+}
+
+//------------------------------------------------------------------------------
+
+// valueSpecType returns the type of a variable declared with a ValueSpec, e.g. "var a, b = 1, 2".
+func (tr *typeResolver) valueSpecType(valueSpec *fileparser.Node, name string) (t goType, ok bool) {
+	if nType := valueSpec.ValueSpecType(); nType != nil {
+		return tr.typeExprType(nType)
+	}
+	var values = valueSpec.ValueSpecValues()
+	for i, declaredName := range valueSpec.DeclaredNames() {
+		if declaredName != name {
+			continue
+		}
+		if len(values) == len(valueSpec.DeclaredNames()) {
+			return tr.exprType(values[i])
+		} else if len(values) == 1 {
+			var types = tr.exprTypes(values[0])
+			if i < len(types) {
+				return types[i], true
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// typeExprType returns the type written in a type expression, e.g. "*sql.DB".
+func (tr *typeResolver) typeExprType(n *fileparser.Node) (t goType, ok bool) {
+	switch n.TypeStr {
+	case "Ident":
+		if builtinTypes[n.Name] {
+			return goType{name: n.Name}, true
+		}
+		return goType{pkgPath: tr.pkg.packageName, pkgName: tr.pkg.packageName, name: n.Name}, true
+	case "SelectorExpr":
+		if importPath, isImport := tr.getImportPath(n.Children[0]); isImport {
+			return goType{pkgPath: importPath, pkgName: fileparser.DefaultPackageName(importPath), name: n.Children[1].Name}, true
+		}
+	case "StarExpr":
+		t, ok = tr.typeExprType(n.Children[0])
+		t.pointer = true
+	case "ParenExpr":
+		return tr.typeExprType(n.Children[0])
+	case "IndexExpr", "IndexListExpr": // generic type
+		return tr.typeExprType(n.Children[0])
+	case "ArrayType", "MapType", "ChanType", "FuncType", "InterfaceType", "StructType", "Ellipsis":
+		return goType{name: n.Bytes}, true
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// callResultTypes returns the types of the results of a function call.
+func (tr *typeResolver) callResultTypes(nCall *fileparser.Node) (types []goType) {
+
+	if len(nCall.Children) == 0 {
+		return
+	}
+	var nFun = nCall.Children[0]
+	var args = nCall.CallArgs()
+
+	switch nFun.TypeStr {
+
+	case "Ident": // foo(...)
+		if (nFun.Name == "new" || nFun.Name == "make") && len(args) > 0 {
+			if t, ok := tr.typeExprType(args[0]); ok {
+				t.pointer = (nFun.Name == "new")
+				return []goType{t}
+			}
+			return
+		}
+		if builtinTypes[nFun.Name] { // conversion
+			return []goType{{name: nFun.Name}}
+		}
+		if nFun.FindLocalDeclaration(nFun.Name) != nil {
+			return // e.g. a function in a variable
+		}
+		if symbol := tr.pkg.symbols.lookupDecl(nFun.Name, "func"); symbol != nil {
+			return tr.forFile(tr.pkg, symbol.filename).funcDeclResultTypes(symbol.declNode)
+		}
+		if symbol := tr.pkg.symbols.lookupDecl(nFun.Name, "type"); symbol != nil { // conversion
+			return []goType{{pkgPath: tr.pkg.packageName, pkgName: tr.pkg.packageName, name: nFun.Name}}
+		}
+
+	case "SelectorExpr": // pkg.Foo(...) or foo.Method(...)
+		var nX = nFun.Children[0]
+		var selName = nFun.Children[1].Name
+		if importPath, isImport := tr.getImportPath(nX); isImport {
+			if value, ok := getKnownResultTypes().Find(importPath + "." + selName); ok {
+				return value.([]goType)
+			}
+			if pkg := tr.findScannedPackage(importPath); pkg != nil {
+				if symbol := pkg.symbols.lookupDecl(selName, "func"); symbol != nil {
+					return tr.forFile(pkg, symbol.filename).funcDeclResultTypes(symbol.declNode)
+				}
+				if pkg.symbols.lookupDecl(selName, "type") != nil { // conversion
+					return []goType{{pkgPath: pkg.packageName, pkgName: pkg.packageName, name: selName}}
+				}
+			}
+			return
+		}
+		if xType, ok := tr.exprType(nX); ok {
+			return tr.methodResultTypes(xType, selName)
+		}
+
+	case "ParenExpr", "ArrayType", "StarExpr": // conversion
+		if t, ok := tr.typeExprType(nFun); ok {
+			return []goType{t}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// methodResultTypes returns the types of the results of a method of a type.
+func (tr *typeResolver) methodResultTypes(t goType, methodName string) (types []goType) {
+	if tr.depth > constMaxTypeResolverDepth {
+		return
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	if value, ok := getKnownResultTypes().Find(t.pkgPath + "." + t.name + "." + methodName); ok {
+		return value.([]goType)
+	}
+	if pkg := tr.findScannedPackage(t.pkgPath); pkg != nil {
+		if symbol := pkg.symbols.lookupDecl(t.name+"."+methodName, "method"); symbol != nil {
+			return tr.forFile(pkg, symbol.filename).funcDeclResultTypes(symbol.declNode)
+		}
+		for _, embeddedType := range tr.embeddedTypes(t) {
+			if types = tr.methodResultTypes(embeddedType, methodName); types != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// funcSymbol returns the declaration in the scanned packages of the function or the method designated by
+// an expression, e.g. "foo", "pkg.Foo", "x.Method" (also a method of an embedded type), the method
+// expression "(*T).Method", or a local variable assigned with one of them (e.g. "f" after "f := x.Method"),
+// or nil.
+func (tr *typeResolver) funcSymbol(n *fileparser.Node) *symbolInfo {
+
+	if tr.depth > constMaxTypeResolverDepth {
+		return nil
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	switch n.TypeStr {
+	case "ParenExpr":
+		return tr.funcSymbol(n.Children[0])
+	case "Ident":
+		if declNode := n.FindLocalDeclaration(n.Name); declNode != nil {
+			if nValue := getDeclaredValue(declNode, n.Name); nValue != nil && nValue != n && nValue.TypeStr != "CallExpr" {
+				return tr.funcSymbol(nValue) // method value, e.g. "f := x.Method"
+			}
+			return nil
+		}
+		return tr.pkg.symbols.lookupDecl(n.Name, "func")
+	case "SelectorExpr":
+		var nX = n.Children[0]
+		var selName = n.Children[1].Name
+		if importPath, isImport := tr.getImportPath(nX); isImport {
+			if pkg := tr.findScannedPackage(importPath); pkg != nil {
+				return pkg.symbols.lookupDecl(selName, "func")
+			}
+			return nil
+		}
+		if xType, ok := tr.exprType(nX); ok {
+			return tr.methodSymbol(xType, selName)
+		}
+		if nX.TypeStr == "ParenExpr" || (nX.TypeStr == "Ident" && nX.FindLocalDeclaration(nX.Name) == nil && tr.pkg.symbols.lookupDecl(nX.Name, "type") != nil) ||
+			(nX.TypeStr == "SelectorExpr" && nX.Children[0].TypeStr == "Ident") { // method expression, e.g. "(*T).Method" or "pkg.T.Method"
+			if t, ok := tr.typeExprType(nX); ok {
+				return tr.methodSymbol(t, selName)
+			}
+		}
+	}
+	return nil
+}
+
+// methodSymbol returns the declaration of a method of a type declared in the scanned packages
+// (or of a type embedded in it), or nil.
+func (tr *typeResolver) methodSymbol(t goType, methodName string) *symbolInfo {
+	if tr.depth > constMaxTypeResolverDepth {
+		return nil
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	var pkg = tr.findScannedPackage(t.pkgPath)
+	if pkg == nil {
+		return nil
+	}
+	if symbol := pkg.symbols.lookupDecl(t.name+"."+methodName, "method"); symbol != nil {
+		return symbol
+	}
+	for _, embeddedType := range tr.embeddedTypes(t) {
+		if symbol := tr.methodSymbol(embeddedType, methodName); symbol != nil {
+			return symbol
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (tr *typeResolver) funcDeclResultTypes(nFuncDecl *fileparser.Node) (types []goType) {
+	for _, nType := range nFuncDecl.FuncResultTypes() {
+		var t, ok = tr.typeExprType(nType)
+		if !ok {
+			return nil
+		}
+		types = append(types, t)
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// fieldType returns the type of the field of a struct declared in the scanned packages.
+func (tr *typeResolver) fieldType(t goType, fieldName string) (fieldType goType, ok bool) {
+	if tr.depth > constMaxTypeResolverDepth {
+		return
+	}
+	tr.depth++
+	defer func() { tr.depth-- }()
+
+	var pkg, typeSpec, filename = tr.findTypeSpec(t)
+	if typeSpec == nil {
+		return
+	}
+	var tr2 = tr.forFile(pkg, filename)
+	for _, nField := range typeSpec.StructFields() {
+		for _, name := range nField.DeclaredNames() {
+			if name == fieldName {
+				return tr2.typeExprType(nField.FieldType())
+			}
+		}
+	}
+	for _, embeddedType := range tr.embeddedTypes(t) {
+		if embeddedType.name == fieldName {
+			return embeddedType, true
+		}
+		if fieldType, ok = tr.fieldType(embeddedType, fieldName); ok {
+			return
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// embeddedTypes returns the types embedded in a struct declared in the scanned packages.
+func (tr *typeResolver) embeddedTypes(t goType) (types []goType) {
+	if tr.depth > constMaxTypeResolverDepth {
+		return
+	}
+	var pkg, typeSpec, filename = tr.findTypeSpec(t)
+	if typeSpec == nil {
+		return
+	}
+	var tr2 = tr.forFile(pkg, filename)
+	for _, nField := range typeSpec.StructFields() {
+		if len(nField.DeclaredNames()) == 0 {
+			if embeddedType, ok := tr2.typeExprType(nField.FieldType()); ok {
+				types = append(types, embeddedType)
+			}
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// compositeLitType returns the type of a composite literal, e.g. "Foo{...}", or "{...}" in "[]Foo{{...}}"
+// or in "map[string]*Foo{"a": {...}}".
+func (tr *typeResolver) compositeLitType(n *fileparser.Node) (t goType, ok bool) {
+	if len(n.Children) > len(n.CompositeLitElts()) {
+		return tr.typeExprType(n.Children[0])
+	}
+	var nOuter = n.Father // the type is elided
+	if nOuter != nil && nOuter.TypeStr == "KeyValueExpr" {
+		nOuter = nOuter.Father
+	}
+	if nOuter == nil || nOuter.TypeStr != "CompositeLit" || len(nOuter.Children) <= len(nOuter.CompositeLitElts()) {
+		return
+	}
+	var nOuterType = nOuter.Children[0]
+	if (nOuterType.TypeStr != "ArrayType" && nOuterType.TypeStr != "MapType") || len(nOuterType.Children) == 0 {
+		return
+	}
+	t, ok = tr.typeExprType(nOuterType.Children[len(nOuterType.Children)-1])
+	t.pointer = false // "{...}" for "&Foo{...}"
+	return
+}
+
+// structFieldIndex returns the index of a field in the declaration of a struct type, or -1 if unknown.
+func (tr *typeResolver) structFieldIndex(t goType, fieldName string) int {
+	var _, typeSpec, _ = tr.findTypeSpec(t)
+	if typeSpec == nil {
+		return -1
+	}
+	var index = 0
+	for _, nField := range typeSpec.StructFields() {
+		var names = nField.DeclaredNames()
+		if len(names) == 0 { // embedded
+			index++
+		}
+		for _, name := range names {
+			if name == fieldName {
+				return index
+			}
+			index++
+		}
+	}
+	return -1
+}
+
+//------------------------------------------------------------------------------
+
+// findTypeSpec returns the declaration of a type declared in the scanned packages, or nil.
+func (tr *typeResolver) findTypeSpec(t goType) (pkg *packageInfos, typeSpec *fileparser.Node, filename string) {
+	pkg = tr.findScannedPackage(t.pkgPath)
+	if pkg == nil {
+		return
+	}
+	if symbol := pkg.symbols.lookupDecl(t.name, "type"); symbol != nil {
+		return pkg, symbol.declNode, symbol.filename
+	}
+	return nil, nil, ""
+}
+
+//------------------------------------------------------------------------------
+
+// findScannedPackage returns the scanned package matching an import path or a package name, or nil.
+func (tr *typeResolver) findScannedPackage(pkgPath string) *packageInfos {
+	if pkgPath == "" {
+		return nil
+	}
+	if pkgPath == tr.pkg.packageName {
+		return tr.pkg
+	}
+	if pkg, ok := tr.mInfosByPackageName[pkgPath]; ok {
+		return pkg
+	}
+	if strings.Contains(pkgPath, "/") || strings.HasPrefix(pkgPath, ".") {
+		if pkg, ok := tr.mInfosByPackageName[fileparser.DefaultPackageName(pkgPath)]; ok {
+			return pkg
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// getImportPath returns the import path if n is the name of an imported package (and not
+// a local variable with the same name).
+func (tr *typeResolver) getImportPath(n *fileparser.Node) (importPath string, isImport bool) {
+	if n.TypeStr != "Ident" {
+		return
+	}
+	importPath, isImport = tr.imports[n.Name]
+	if isImport && n.FindLocalDeclaration(n.Name) != nil {
+		return "", false
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -1,10 +1,11 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
-	"io"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -88,31 +89,72 @@ func DebugPrintf(message string, args ...interface{}) {
 
 //------------------------------------------------------------------------------
 
-func RunCmdWithStdin(stdinStr string, cmdName string, cmdArgs []string) (cmdOutput string, exitCode int) {
+// RunCmdWithStdin runs a command with stdinStr as standard input, and returns its output (standard and error)
+// and its exit code. err is not nil if the command cannot be run (e.g. not found), or is killed after the
+// timeout (0: no timeout).
+func RunCmdWithStdin(stdinStr string, timeout time.Duration, cmdName string, cmdArgs []string) (cmdOutput string, exitCode int, err error) {
 	//fmt.Printf("%s %s\n", stdinStr, cmdName)
 	var cmd = exec.Command(cmdName, cmdArgs...)
-	var stdin, err = cmd.StdinPipe()
-	if err != nil {
-		panic(err)
+	var out bytes.Buffer
+	cmd.Stdin = strings.NewReader(stdinStr)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err = cmd.Start(); err != nil {
+		return "", -1, fmt.Errorf("cannot run %s: %s", cmdName, err.Error())
 	}
 
+	var done = make(chan error, 1)
 	go func() {
-		defer stdin.Close()
-		io.WriteString(stdin, stdinStr)
+		done <- cmd.Wait()
 	}()
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timeoutChan = time.After(timeout)
+	}
+	select {
+	case err = <-done:
+	case <-timeoutChan:
+		cmd.Process.Kill() // without waiting for its children, which may still use the output
+		return "", -1, fmt.Errorf("%s killed after a timeout of %s", cmdName, timeout)
+	}
+
+	cmdOutput = out.String()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return cmdOutput, exitErr.ExitCode(), nil
+	} else if err != nil {
+		return cmdOutput, -1, fmt.Errorf("cannot run %s: %s", cmdName, err.Error())
+	}
+	return cmdOutput, 0, nil
+}
 
-	var out []byte
-	out, err = cmd.CombinedOutput()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			panic(err)
+//------------------------------------------------------------------------------
+
+// LevenshteinDistance returns the number of single-character edits needed to change a into b.
+func LevenshteinDistance(a string, b string) int {
+	var previous = make([]int, len(b)+1)
+	var current = make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			var cost = 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
 		}
+		previous, current = current, previous
 	}
+	return previous[len(b)]
+}
 
-	cmdOutput = string(out)
-	return
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
 }
 
 //------------------------------------------------------------------------------